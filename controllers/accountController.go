@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/email"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+const defaultAccountDeletionGraceDays = 30
+
+// @Summary Delete account
+// @Description Schedules the authenticated user's account for deletion. The account is disabled straight away and everything in it is permanently deleted once the grace period (30 days by default) is over; until then it can be restored. Download your data first with GET /auth/export.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param confirmation body models.AccountDeletionRequest true "Current password"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/account [DELETE]
+func DeleteAccount(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var request models.AccountDeletionRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Please confirm with your password", err)
+		return
+	}
+
+	err = models.CheckPassword(userIdStr, request.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPassword) {
+			utils.RespondError(c, http.StatusUnauthorized, "Password is incorrect", nil)
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Could not delete account", err)
+		return
+	}
+
+	profile, err := models.GetProfile(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch user data", err)
+		return
+	}
+
+	graceDays := utils.EnvInt("ACCOUNT_DELETION_GRACE_DAYS", defaultAccountDeletionGraceDays)
+	purgeAfter, err := models.ScheduleAccountDeletion(userIdStr, int(graceDays))
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not delete account", err)
+		return
+	}
+
+	body := fmt.Sprintf(`
+		<p>We have received your request to delete your JobStar account.</p>
+		<p>Your account has been disabled and all of your data will be permanently deleted on %s.</p>
+		<p>If you change your mind before then, you can restore your account with your email and password.</p>
+	`, purgeAfter.UTC().Format("2 January 2006"))
+
+	err = email.SendEmail(profile.Email, "Your JobStar account is scheduled for deletion", profile.FirstName, body)
+	if err != nil {
+		// The deletion itself has gone through; the notice is a courtesy
+		log.Printf("Error sending account deletion notice to user %s: %v", userIdStr, err)
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Account scheduled for deletion", gin.H{
+		"purgeAfter": purgeAfter,
+	})
+}
+
+// @Summary Restore account
+// @Description Cancels a pending account deletion while the grace period is still running
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param credentials body models.AccountRestoreRequest true "Email and password"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/account/restore [POST]
+func RestoreAccount(c *gin.Context) {
+	var request models.AccountRestoreRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Please provide email and password", err)
+		return
+	}
+
+	err = models.RestoreAccount(request.Email, request.Password)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Could not restore account", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Account restored, you can log in again", nil)
+}
@@ -3,7 +3,10 @@ package controllers
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -117,7 +120,7 @@ func RegistrationController(c *gin.Context) {
 }
 
 // @Summary Login a user
-// @Description Authenticates a user and returns a JWT token
+// @Description Authenticates a user and returns a JWT token. With two-factor authentication on, it returns twoFactorRequired and a short-lived preAuthToken instead, to be exchanged for a token at POST /auth/2fa/verify.
 // @Tags Auth
 // @Accept  json
 // @Produce  json
@@ -125,6 +128,7 @@ func RegistrationController(c *gin.Context) {
 // @Success 200 {object} models.AuthResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Router /auth/login [POST]
 func LoginController(c *gin.Context) {
 	var user models.UserLogin
@@ -146,11 +150,20 @@ func LoginController(c *gin.Context) {
 
 	err = user.ValidateCredentials()
 
+	if errors.Is(err, models.ErrAccountPendingDeletion) {
+		utils.RespondError(c, http.StatusForbidden, "This account is scheduled for deletion, restore it to log in", err)
+		return
+	}
 	if err != nil {
 		utils.RespondError(c, http.StatusBadRequest, "Invalid credentials", err)
 		return
 	}
 
+	if user.TwoFactorEnabled {
+		respondTwoFactorRequired(c, user.ID)
+		return
+	}
+
 	token, err := utils.GenerateToken(user.Email, user.ID) // we are able to access user.ID cos it has been binded in ValidateCredentials
 
 	if err != nil {
@@ -165,15 +178,16 @@ func LoginController(c *gin.Context) {
 
 // UpdateUser updates a user's details
 // @Summary Update user details
-// @Description Updates the authenticated user's details
+// @Description Changes only the details supplied, given as an RFC 7396 merge patch (Content-Type application/merge-patch+json, or application/json) or an RFC 6902 JSON Patch (Content-Type application/json-patch+json). The result must still have a first name, last name and location.
 // @Tags Auth
 // @Security ApiKeyAuth
 // @Accept  json
 // @Produce  json
-// @Param user body models.UserUpdateRequest true "User Update Data"
+// @Param user body models.UserUpdateRequest true "Details to change"
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 415 {object} models.ErrorResponse
 // @Router /auth/updateUser [PATCH]
 func UpdateUser(c *gin.Context) {
 	userId, exists := c.Get("userId")
@@ -190,6 +204,76 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	profile, err := models.GetProfile(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch user data", err)
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Could not read request data", err)
+		return
+	}
+
+	current, err := json.Marshal(models.UserUpdate{
+		FirstName:         profile.FirstName,
+		LastName:          profile.LastName,
+		Location:          profile.Location,
+		PreferredCurrency: profile.PreferredCurrency,
+	})
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to update user details", err)
+		return
+	}
+
+	patched, err := utils.ApplyPatch(c.ContentType(), current, patch)
+	if err != nil {
+		if errors.Is(err, utils.ErrUnsupportedPatchType) {
+			utils.RespondError(c, http.StatusUnsupportedMediaType, "Unsupported patch format", err)
+			return
+		}
+		utils.RespondError(c, http.StatusBadRequest, "Could not apply patch", err)
+		return
+	}
+
+	var user models.UserUpdate
+	err = json.Unmarshal(patched, &user)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid user details", err)
+		return
+	}
+
+	saveUserUpdate(c, userIdStr, &user)
+}
+
+// ReplaceUser replaces a user's details
+// @Summary Replace user details
+// @Description Replaces the authenticated user's details; every field is required
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param user body models.UserUpdateRequest true "User Update Data"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/updateUser [PUT]
+func ReplaceUser(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
 	var user models.UserUpdate
 	err := c.ShouldBindJSON(&user)
 
@@ -198,19 +282,25 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
-	user.ID = userIdStr
+	saveUserUpdate(c, userIdStr, &user)
+}
+
+func saveUserUpdate(c *gin.Context, userId string, user *models.UserUpdate) {
+	user.ID = userId
 
-	// Validate required fields
-	if user.FirstName == "" {
-		utils.RespondError(c, http.StatusBadRequest, "Please provide first name", nil)
+	err := user.Validate()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid user details", err)
 		return
 	}
-	if user.LastName == "" {
-		utils.RespondError(c, http.StatusBadRequest, "Please provide last name", nil)
+
+	known, err := models.ExchangeRateExists(user.PreferredCurrency)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to update user details", err)
 		return
 	}
-	if user.Location == "" {
-		utils.RespondError(c, http.StatusBadRequest, "Please provide first name", nil)
+	if !known {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid user details", fmt.Errorf("no exchange rate for %s", user.PreferredCurrency))
 		return
 	}
 
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+// @Summary Change many jobs at once
+// @Description Applies one action to a list of job IDs, or to every job matching a filter written like the job listing query string (e.g. "status=pending&tags=fintech&archived=all"). Actions are status (needs status), addTags and removeTags (need tags), archive, unarchive and delete (moves to the trash). Everything happens in one transaction: if any of the IDs is not one of your jobs, nothing is changed and the response says which. Up to 1000 jobs per request.
+// @Tags Job
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param request body models.BulkJobRequest true "Action and target jobs"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.SuccessResponse
+// @Router /jobs/bulk [POST]
+func BulkUpdateJobs(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var request models.BulkJobRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Could not parse request data", err)
+		return
+	}
+
+	op, err := bulkOperation(request)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid bulk action", err)
+		return
+	}
+
+	var filter *models.JobFilter
+	switch {
+	case len(request.IDs) > 0 && request.Filter != "":
+		utils.RespondError(c, http.StatusBadRequest, "Please provide either ids or a filter, not both", nil)
+		return
+	case len(request.IDs) > models.MaxBulkJobs:
+		utils.RespondError(c, http.StatusBadRequest, fmt.Sprintf("At most %d jobs can be changed at once", models.MaxBulkJobs), nil)
+		return
+	case request.Filter != "":
+		query, err := url.ParseQuery(request.Filter)
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "Invalid filter", err)
+			return
+		}
+		parsed, err := parseJobFilterValues(query)
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "Invalid filter", err)
+			return
+		}
+		filter = &parsed
+	case len(request.IDs) == 0:
+		utils.RespondError(c, http.StatusBadRequest, "Please provide ids or a filter", nil)
+		return
+	}
+
+	results, err := models.BulkUpdateJobs(userIdStr, request.IDs, filter, op)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrBulkJobsNotFound):
+			utils.RespondJSON(c, http.StatusNotFound, "Some jobs were not found, nothing was changed", gin.H{
+				"results": results,
+			})
+		case errors.Is(err, models.ErrTooManyBulkJobs):
+			utils.RespondError(c, http.StatusBadRequest, fmt.Sprintf("The filter matches more than %d jobs", models.MaxBulkJobs), err)
+		default:
+			utils.RespondError(c, http.StatusInternalServerError, "Could not update jobs", err)
+		}
+		return
+	}
+
+	updated := 0
+	for _, result := range results {
+		if result.Result == models.BulkResultUpdated {
+			updated++
+		}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Jobs updated successfully", gin.H{
+		"action":  op.Action,
+		"matched": len(results),
+		"updated": updated,
+		"results": results,
+	})
+}
+
+// bulkOperation checks the request carries what its action needs.
+func bulkOperation(request models.BulkJobRequest) (models.BulkJobOperation, error) {
+	op := models.BulkJobOperation{Action: request.Action}
+
+	switch {
+	case !request.Action.BulkActionIsValid():
+		return op, errors.New("action must be status, addTags, removeTags, archive, unarchive or delete")
+
+	case request.Action == models.BulkSetStatus:
+		if !request.Status.StatusIsValid() {
+			return op, errors.New("invalid status")
+		}
+		op.Status = request.Status
+
+	case request.Action == models.BulkAddTags, request.Action == models.BulkRemoveTags:
+		if len(request.Tags) == 0 {
+			return op, errors.New("please provide at least one tag")
+		}
+		for _, tag := range request.Tags {
+			name, err := models.NormalizeTagName(tag)
+			if err != nil {
+				return op, err
+			}
+			op.Tags = append(op.Tags, name)
+		}
+	}
+
+	return op, nil
+}
@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+// @Summary Get companies
+// @Description Lists the companies the authenticated user has applied to, with application counts and outcomes, most applied to first
+// @Tags Company
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /companies [GET]
+func GetCompanies(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	companies, err := models.GetUserCompanies(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch companies", err)
+		return
+	}
+
+	if companies == nil {
+		companies = []models.Company{}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"companies": companies,
+	})
+}
+
+// @Summary Get a company
+// @Description Gets one of the authenticated user's companies with its aliases, outcomes and the jobs under it
+// @Tags Company
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Company ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /companies/{id} [GET]
+func GetSingleCompany(c *gin.Context) {
+	companyId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	company, err := models.GetUserCompanyById(companyId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch company", err)
+		return
+	}
+
+	jobs, err := models.GetCompanyJobs(companyId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch company jobs", err)
+		return
+	}
+
+	if jobs == nil {
+		jobs = []models.Job{}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"company": company,
+		"jobs":    jobs,
+	})
+}
+
+// @Summary Rename a company
+// @Description Changes the display name of one of the authenticated user's companies. The new name is kept as an alias.
+// @Tags Company
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Company ID"
+// @Param company body models.CompanyRequest true "Company Data"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /companies/{id} [PATCH]
+func RenameCompany(c *gin.Context) {
+	companyId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	request, ok := bindCompanyRequest(c)
+	if !ok {
+		return
+	}
+
+	company, err := models.GetUserCompanyById(companyId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch company", err)
+		return
+	}
+
+	err = company.Rename(request.Name)
+	if err != nil {
+		if errors.Is(err, models.ErrCompanyNameTaken) {
+			utils.RespondError(c, http.StatusConflict, "Company name is taken", err)
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Could not rename company", err)
+		return
+	}
+
+	respondWithCompany(c, companyId, userIdStr, "Company renamed successfully")
+}
+
+// @Summary Add a company alias
+// @Description Makes one of the authenticated user's companies known by another name and links jobs saved under it. If another of the user's companies already goes by that name, it is merged into this one.
+// @Tags Company
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Company ID"
+// @Param alias body models.CompanyRequest true "Alias"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /companies/{id}/aliases [POST]
+func AddCompanyAlias(c *gin.Context) {
+	companyId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	request, ok := bindCompanyRequest(c)
+	if !ok {
+		return
+	}
+
+	company, err := models.GetUserCompanyById(companyId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch company", err)
+		return
+	}
+
+	err = company.AddAlias(request.Name)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not add alias", err)
+		return
+	}
+
+	respondWithCompany(c, companyId, userIdStr, "Alias added successfully")
+}
+
+// bindCompanyRequest reads a company name from the body, responding 400 when
+// it is missing or has nothing left once normalised.
+func bindCompanyRequest(c *gin.Context) (models.CompanyRequest, bool) {
+	var request models.CompanyRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return request, false
+	}
+
+	request.Name = strings.TrimSpace(request.Name)
+	if models.NormalizeCompanyName(request.Name) == "" {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid company name", errors.New("please provide company name"))
+		return request, false
+	}
+
+	return request, true
+}
+
+func respondWithCompany(c *gin.Context, companyId, userId, message string) {
+	company, err := models.GetUserCompanyById(companyId, userId)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch company", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, message, gin.H{
+		"company": company,
+	})
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+// @Summary Compare offers
+// @Description Lines up jobs side by side with their salaries converted to yearly amounts in one currency, along with location and job type. Hourly, daily, weekly and monthly pay assume a 40-hour, 5-day week all year. normalized is null for jobs without a salary or in a currency with no exchange rate.
+// @Tags Job
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   ids   query    string  true  "Comma-separated job IDs, up to 10"
+// @Param   currency   query    string  false  "Currency to compare in (defaults to your preferred currency)"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/compare [GET]
+func CompareJobs(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var ids []string
+	seen := map[string]bool{}
+	for _, id := range strings.Split(c.Query("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		utils.RespondError(c, http.StatusBadRequest, "Please provide ids", nil)
+		return
+	}
+	if len(ids) > models.MaxCompareJobs {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Sprintf("Please compare at most %d jobs", models.MaxCompareJobs), nil)
+		return
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(c.Query("currency")))
+	if currency == "" {
+		preferred, err := models.GetPreferredCurrency(userIdStr)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "Could not fetch preferred currency", err)
+			return
+		}
+		currency = preferred
+	}
+
+	known, err := models.ExchangeRateExists(currency)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not compare jobs", err)
+		return
+	}
+	if !known {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid currency", fmt.Errorf("no exchange rate for %s", currency))
+		return
+	}
+
+	comparisons, missing, err := models.CompareJobs(ids, userIdStr, currency)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not compare jobs", err)
+		return
+	}
+	if len(missing) > 0 {
+		utils.RespondError(c, http.StatusNotFound, "Some jobs were not found",
+			errors.New("not found: "+strings.Join(missing, ", ")))
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"currency": currency,
+		"period":   models.PerYear,
+		"jobs":     comparisons,
+	})
+}
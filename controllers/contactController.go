@@ -0,0 +1,449 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+// @Summary Create a contact
+// @Description Adds a recruiter, hiring manager or other contact to the authenticated user's address book
+// @Tags Contact
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param contact body models.ContactRequest true "Contact Data"
+// @Success 201 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /contacts [POST]
+func CreateContact(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var contact models.Contact
+	err := c.ShouldBindJSON(&contact)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	err = contact.Validate()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid contact data", err)
+		return
+	}
+
+	contact.UserID = userIdStr
+
+	err = contact.Save()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not create contact", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusCreated, "Contact created successfully", gin.H{
+		"contact": contact,
+	})
+}
+
+// @Summary Search contacts
+// @Description Lists the authenticated user's contacts, optionally filtered by a search term matched against name, email, phone and company
+// @Tags Contact
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   q   query    string  false  "Search term"
+// @Param   page   query    int  false  "Page number (default 1)"
+// @Param   limit   query    int  false  "Contacts per page (default 20, max 100)"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /contacts [GET]
+func GetContacts(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	pagination := utils.ParsePagination(c)
+
+	contacts, total, err := models.SearchContacts(userIdStr, c.Query("q"), pagination.Limit, pagination.Offset())
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch contacts", err)
+		return
+	}
+
+	if contacts == nil {
+		contacts = []models.Contact{}
+	}
+	pagination.SetTotal(total)
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"contacts":   contacts,
+		"pagination": pagination,
+	})
+}
+
+// @Summary Get contact by ID
+// @Description Retrieves a single contact
+// @Tags Contact
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Contact ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /contacts/{id} [GET]
+func GetSingleContact(c *gin.Context) {
+	contactId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	contact, err := models.GetUserContactById(contactId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch contact", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"contact": contact,
+	})
+}
+
+// @Summary Update a contact
+// @Description Updates a contact's details
+// @Tags Contact
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Contact ID"
+// @Param contact body models.ContactRequest true "Contact Data"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /contacts/{id} [PATCH]
+func UpdateContact(c *gin.Context) {
+	contactId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var contact models.Contact
+	err := c.ShouldBindJSON(&contact)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	err = contact.Validate()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid contact data", err)
+		return
+	}
+
+	contact.ID = contactId
+	contact.UserID = userIdStr
+
+	err = contact.Update()
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Could not update contact", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Contact updated successfully", nil)
+}
+
+// @Summary Delete a contact
+// @Description Deletes a contact and unlinks it from every job
+// @Tags Contact
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Contact ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /contacts/{id} [DELETE]
+func DeleteContact(c *gin.Context) {
+	contactId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	contact, err := models.GetUserContactById(contactId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch contact", err)
+		return
+	}
+
+	err = contact.Delete()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not delete contact", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Contact deleted successfully", nil)
+}
+
+// @Summary Get jobs a contact touched
+// @Description Lists every job the contact is linked to, with the role they played on each
+// @Tags Contact
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Contact ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /contacts/{id}/jobs [GET]
+func GetContactJobs(c *gin.Context) {
+	contactId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserContactById(contactId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch contact", err)
+		return
+	}
+
+	jobs, err := models.GetContactJobs(contactId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch jobs", err)
+		return
+	}
+
+	if jobs == nil {
+		jobs = []models.ContactJob{}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"jobs": jobs,
+	})
+}
+
+// @Summary Link a contact to a job
+// @Description Records that a contact was involved in a job as recruiter, hiring_manager or referrer
+// @Tags Contact
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param link body models.JobContactRequest true "Link Data"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/contacts [POST]
+func LinkJobContact(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var request models.JobContactRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	role := models.ContactRole(request.Role)
+	if !role.RoleIsValid() {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid role", nil)
+		return
+	}
+
+	_, err = models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	_, err = models.GetUserContactById(request.ContactID, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch contact", err)
+		return
+	}
+
+	err = models.LinkContact(jobId, request.ContactID, role)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not link contact", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Contact linked successfully", nil)
+}
+
+// @Summary Get contacts for a job
+// @Description Lists the contacts linked to a job with their roles
+// @Tags Contact
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/contacts [GET]
+func GetJobContacts(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	contacts, err := models.GetJobContacts(jobId)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch contacts", err)
+		return
+	}
+
+	if contacts == nil {
+		contacts = []models.JobContact{}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"contacts": contacts,
+	})
+}
+
+// @Summary Unlink a contact from a job
+// @Description Removes a contact from a job. Pass role to remove only that role, otherwise all of the contact's roles on the job are removed.
+// @Tags Contact
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param   contactId   path    string  true  "Contact ID"
+// @Param   role   query    string  false  "Role to remove"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/contacts/{contactId} [DELETE]
+func UnlinkJobContact(c *gin.Context) {
+	jobId := c.Param("id")
+	contactId := c.Param("contactId")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	role := models.ContactRole(c.Query("role"))
+	if role != "" && !role.RoleIsValid() {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid role", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	err = models.UnlinkContact(jobId, contactId, role)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Could not unlink contact", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Contact unlinked successfully", nil)
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+// @Summary Get weekly digest settings
+// @Description Returns the authenticated user's weekly digest email settings
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/digest [GET]
+func GetDigestSettings(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	settings, err := models.GetDigestSettings(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch digest settings", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"digest": settings,
+	})
+}
+
+// @Summary Update weekly digest settings
+// @Description Opts in or out of the weekly digest email and sets the day and hour it is sent in the user's timezone. Fields left out keep their current value. The timezone is part of your preferences and is changed with PATCH /auth/preferences.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param settings body models.DigestSettingsRequest true "Digest Settings"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/digest [PATCH]
+func UpdateDigestSettings(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	current, err := models.GetDigestSettings(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch digest settings", err)
+		return
+	}
+
+	// Decode over the current settings so fields left out keep their value
+	settings := *current
+	err = c.ShouldBindJSON(&settings)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	if settings.Timezone != current.Timezone {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid digest settings", errors.New("the timezone is shared with your other preferences, change it with PATCH /auth/preferences"))
+		return
+	}
+
+	err = settings.Validate()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid digest settings", err)
+		return
+	}
+
+	err = settings.Update(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to update digest settings", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Digest settings updated successfully", nil)
+}
+
+// unsubscribePage is shown for the unsubscribe link in digest emails. The
+// link only shows it; unsubscribing takes the POST from its button, so mail
+// scanners and link prefetchers following the link change nothing.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>JobStar weekly digest</title>
+</head>
+<body style="font-family: Arial, sans-serif; color: #333333; text-align: center; padding: 40px;">
+    <p>{{ .Message }}</p>
+    {{ if .Token }}
+    <form method="post" action="?t={{ .Token }}">
+        <button type="submit">Unsubscribe</button>
+    </form>
+    {{ end }}
+</body>
+</html>
+`))
+
+func renderUnsubscribePage(c *gin.Context, status int, message, token string) {
+	var page bytes.Buffer
+	err := unsubscribePage.Execute(&page, gin.H{"Message": message, "Token": token})
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to render page", err)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
+
+// @Summary Confirm unsubscribing from the weekly digest
+// @Description The page behind the unsubscribe link in every digest email. It only asks for confirmation; unsubscribing is done by POST /auth/digest/unsubscribe. No login required.
+// @Tags Auth
+// @Produce  html
+// @Param t query string true "Unsubscribe Token"
+// @Success 200 {string} string "Confirmation page"
+// @Failure 400 {string} string "Error page"
+// @Router /auth/digest/unsubscribe [GET]
+func ConfirmUnsubscribeDigest(c *gin.Context) {
+	token := c.Query("t")
+
+	if token == "" {
+		renderUnsubscribePage(c, http.StatusBadRequest, "This unsubscribe link is incomplete.", "")
+		return
+	}
+
+	valid, err := models.DigestTokenIsValid(token)
+	if err != nil {
+		renderUnsubscribePage(c, http.StatusInternalServerError, "Something went wrong, please try again later.", "")
+		return
+	}
+	if !valid {
+		renderUnsubscribePage(c, http.StatusBadRequest, "This unsubscribe link is no longer valid.", "")
+		return
+	}
+
+	renderUnsubscribePage(c, http.StatusOK, "Stop sending me the JobStar weekly digest?", token)
+}
+
+// @Summary Unsubscribe from the weekly digest
+// @Description Turns off the weekly digest for the owner of the token in the unsubscribe link. Also the target of one-click unsubscribe from mail clients (RFC 8058). No login required.
+// @Tags Auth
+// @Produce  json
+// @Param t query string true "Unsubscribe Token"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/digest/unsubscribe [POST]
+func UnsubscribeDigest(c *gin.Context) {
+	token := c.Query("t")
+	html := c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML
+
+	if token == "" {
+		if html {
+			renderUnsubscribePage(c, http.StatusBadRequest, "This unsubscribe link is incomplete.", "")
+			return
+		}
+		utils.RespondError(c, http.StatusBadRequest, "Token is required", nil)
+		return
+	}
+
+	err := models.UnsubscribeDigest(token)
+	if err != nil {
+		if html {
+			renderUnsubscribePage(c, http.StatusBadRequest, "This unsubscribe link is no longer valid.", "")
+			return
+		}
+		utils.RespondError(c, http.StatusBadRequest, "Unable to unsubscribe", err)
+		return
+	}
+
+	if html {
+		renderUnsubscribePage(c, http.StatusOK, "You have been unsubscribed from the weekly digest.", "")
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "You have been unsubscribed from the weekly digest", nil)
+}
@@ -0,0 +1,359 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/storage"
+	"jobstar.com/api/utils"
+)
+
+const (
+	defaultDocumentMaxBytes   = 10 << 20  // 10 MB per file
+	defaultDocumentQuotaBytes = 100 << 20 // 100 MB per user
+)
+
+// oleMagic starts legacy Microsoft Office (.doc) files.
+var oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// detectDocumentType sniffs the real content type from the first bytes of the
+// file, using the extension only to tell apart formats that share a container
+// (docx and odt are both zip files). It reports false for anything we don't
+// accept.
+func detectDocumentType(head []byte, fileName string) (string, bool) {
+	sniffed := http.DetectContentType(head)
+	extension := strings.ToLower(filepath.Ext(fileName))
+
+	switch {
+	case sniffed == "application/pdf":
+		return sniffed, true
+	case sniffed == "image/png", sniffed == "image/jpeg":
+		return sniffed, true
+	case strings.HasPrefix(sniffed, "text/plain"):
+		return "text/plain; charset=utf-8", true
+	case sniffed == "application/zip" && extension == ".docx":
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document", true
+	case sniffed == "application/zip" && extension == ".odt":
+		return "application/vnd.oasis.opendocument.text", true
+	case bytes.HasPrefix(head, oleMagic) && extension == ".doc":
+		return "application/msword", true
+	}
+
+	return "", false
+}
+
+func newStorageKey(userId, jobId string) (string, error) {
+	random, err := generateVerificationToken()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("documents/%s/%s/%s", userId, jobId, random[:32]), nil
+}
+
+// @Summary Upload a document to a job
+// @Description Uploads a resume, cover letter, offer letter or other file for a job. Accepts PDF, Word, OpenDocument, plain text, PNG and JPEG.
+// @Tags Document
+// @Security ApiKeyAuth
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param   file   formData    file  true  "Document"
+// @Param   kind   formData    string  false  "resume, cover_letter, offer or other (default other)"
+// @Param   Idempotency-Key   header    string  false  "Unique key for this request; retries with the same key get the first response back instead of uploading again"
+// @Success 201 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 415 {object} models.ErrorResponse
+// @Router /jobs/{id}/documents [POST]
+func UploadDocument(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	maxBytes := utils.EnvInt("DOCUMENT_MAX_BYTES", defaultDocumentMaxBytes)
+	quota := utils.EnvInt("DOCUMENT_QUOTA_BYTES", defaultDocumentQuotaBytes)
+
+	// Leave some room for the multipart framing around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.RespondError(c, http.StatusRequestEntityTooLarge, "File is too large", nil)
+			return
+		}
+		utils.RespondError(c, http.StatusBadRequest, "Please provide a file", err)
+		return
+	}
+
+	kind := models.DocumentKind(c.DefaultPostForm("kind", string(models.OtherDoc)))
+	if !kind.KindIsValid() {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid document kind", nil)
+		return
+	}
+
+	used, err := models.GetUserStorageUsed(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not check storage quota", err)
+		return
+	}
+
+	err = models.CheckDocumentLimits(fileHeader.Size, used, maxBytes, quota)
+	switch {
+	case errors.Is(err, models.ErrDocumentTooLarge):
+		utils.RespondError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("File is too large, the limit is %d bytes", maxBytes), nil)
+		return
+	case errors.Is(err, models.ErrDocumentEmpty):
+		utils.RespondError(c, http.StatusBadRequest, "File is empty", nil)
+		return
+	case errors.Is(err, models.ErrQuotaExceeded):
+		utils.RespondError(c, http.StatusRequestEntityTooLarge, "Storage quota exceeded", err)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Could not read file", err)
+		return
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		utils.RespondError(c, http.StatusBadRequest, "Could not read file", err)
+		return
+	}
+	head = head[:n]
+
+	contentType, ok := detectDocumentType(head, fileHeader.Filename)
+	if !ok {
+		utils.RespondError(c, http.StatusUnsupportedMediaType, "Unsupported file type", nil)
+		return
+	}
+
+	key, err := newStorageKey(userIdStr, jobId)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not store file", err)
+		return
+	}
+
+	err = storage.Store.Put(c.Request.Context(), key, io.MultiReader(bytes.NewReader(head), file), fileHeader.Size, contentType)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not store file", err)
+		return
+	}
+
+	document := models.Document{
+		JobID:       jobId,
+		UserID:      userIdStr,
+		Kind:        kind,
+		FileName:    filepath.Base(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+		StorageKey:  key,
+	}
+
+	err = document.SaveWithinQuota(quota)
+	if err != nil {
+		// The blob is useless without its record
+		storage.Store.Delete(c.Request.Context(), key)
+
+		if errors.Is(err, models.ErrQuotaExceeded) {
+			utils.RespondError(c, http.StatusRequestEntityTooLarge, "Storage quota exceeded", err)
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Could not save document", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusCreated, "Document uploaded successfully", gin.H{
+		"document": document,
+	})
+}
+
+// @Summary Get documents for a job
+// @Description Lists the documents attached to a job, newest first
+// @Tags Document
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/documents [GET]
+func GetJobDocuments(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	documents, err := models.GetJobDocuments(jobId)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch documents", err)
+		return
+	}
+
+	if documents == nil {
+		documents = []models.Document{}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"documents": documents,
+	})
+}
+
+// @Summary Download a document
+// @Description Downloads a document attached to one of the authenticated user's jobs
+// @Tags Document
+// @Security ApiKeyAuth
+// @Produce  octet-stream
+// @Param   id   path    string  true  "Job ID"
+// @Param   documentId   path    string  true  "Document ID"
+// @Success 200 {file} file "Document content"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/documents/{documentId} [GET]
+func DownloadDocument(c *gin.Context) {
+	jobId := c.Param("id")
+	documentId := c.Param("documentId")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	document, err := models.GetJobDocument(jobId, documentId)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch document", err)
+		return
+	}
+
+	blob, err := storage.Store.Get(c.Request.Context(), document.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondError(c, http.StatusNotFound, "Document content is missing", err)
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Could not read document", err)
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": document.FileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, document.Size, document.ContentType, blob, nil)
+}
+
+// @Summary Delete a document
+// @Description Deletes a document and its stored file
+// @Tags Document
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param   documentId   path    string  true  "Document ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/documents/{documentId} [DELETE]
+func DeleteDocument(c *gin.Context) {
+	jobId := c.Param("id")
+	documentId := c.Param("documentId")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	document, err := models.GetJobDocument(jobId, documentId)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch document", err)
+		return
+	}
+
+	err = storage.Store.Delete(c.Request.Context(), document.StorageKey)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not delete document", err)
+		return
+	}
+
+	err = document.Delete()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not delete document", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Document deleted successfully", nil)
+}
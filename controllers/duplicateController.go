@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+const (
+	defaultDuplicateSimilarityPercent = 85
+	defaultDuplicateWindowDays        = 90
+)
+
+// newDuplicateFinder loads the user's jobs for duplicate checks, with the
+// threshold and window from the environment.
+func newDuplicateFinder(userId string) (*models.DuplicateFinder, error) {
+	threshold := float64(utils.EnvInt("DUPLICATE_SIMILARITY_PERCENT", defaultDuplicateSimilarityPercent)) / 100
+	if threshold > 1 {
+		threshold = 1
+	}
+	windowDays := utils.EnvInt("DUPLICATE_WINDOW_DAYS", defaultDuplicateWindowDays)
+
+	return models.NewDuplicateFinder(userId, threshold, time.Duration(windowDays)*24*time.Hour)
+}
+
+// @Summary Merge two jobs
+// @Description Folds another job (usually a duplicate) into this one. Its notes, status history, interviews, documents, contacts and tags move over, fields this job leaves empty are filled in from it, this job keeps the earlier creation date, and the other job is deleted.
+// @Tags Job
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "ID of the job to keep"
+// @Param request body models.JobMergeRequest true "ID of the job to merge in"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/merge [POST]
+func MergeJobs(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var request models.JobMergeRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Please provide the job to merge in", err)
+		return
+	}
+
+	if request.SourceID == jobId {
+		utils.RespondError(c, http.StatusBadRequest, "Cannot merge a job into itself", nil)
+		return
+	}
+
+	_, err = models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	_, err = models.GetUserJobById(request.SourceID, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch the job to merge in", err)
+		return
+	}
+
+	err = models.MergeJobs(jobId, request.SourceID, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not merge jobs", err)
+		return
+	}
+
+	job, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch job data", err)
+		return
+	}
+
+	jobs := []models.Job{*job}
+	err = models.LoadJobTags(jobs)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch job data", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Jobs merged successfully", gin.H{
+		"job": jobs[0],
+	})
+}
@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/email"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+const defaultEmailChangeTTLHours = 24
+
+// @Summary Change email address
+// @Description Starts changing the authenticated user's email address. A confirmation link is sent to the new address and a notice to the current one; the change only applies once the link is followed, within 24 hours by default. A new request replaces any pending one.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param change body models.EmailChangeRequest true "New email address and current password"
+// @Success 202 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /auth/email [POST]
+func RequestEmailChange(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var request models.EmailChangeRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Please provide the new email address and your password", err)
+		return
+	}
+
+	err = request.Validate()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid email address", err)
+		return
+	}
+
+	err = models.CheckPassword(userIdStr, request.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPassword) {
+			utils.RespondError(c, http.StatusUnauthorized, "Password is incorrect", nil)
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Could not change email address", err)
+		return
+	}
+
+	token, err := generateVerificationToken()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not change email address", err)
+		return
+	}
+
+	ttlHours := utils.EnvInt("EMAIL_CHANGE_TTL_HOURS", defaultEmailChangeTTLHours)
+	expiresAt := time.Now().Add(time.Duration(ttlHours) * time.Hour)
+
+	currentEmail, firstName, err := models.RequestEmailChange(userIdStr, request.NewEmail, token, expiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrEmailTaken):
+			utils.RespondError(c, http.StatusConflict, "That email address is already in use", err)
+		case errors.Is(err, models.ErrEmailUnchanged):
+			utils.RespondError(c, http.StatusBadRequest, "Invalid email address", err)
+		default:
+			utils.RespondError(c, http.StatusInternalServerError, "Could not change email address", err)
+		}
+		return
+	}
+
+	confirmLink := fmt.Sprintf("%s/api/v1/auth/email/confirm?t=%s", os.Getenv("APIHostURL"), url.QueryEscape(token))
+	body := fmt.Sprintf(`
+		<p>We received a request to use this address for your JobStar account.</p>
+		<p>Please confirm the change by clicking the following link before %s:</p>
+		<p><a href="%s">Confirm your new email address</a></p>
+		<p>If you did not ask for this, you can ignore this email.</p>
+	`, expiresAt.UTC().Format("2 January 2006, 15:04 MST"), confirmLink)
+
+	err = email.SendEmail(request.NewEmail, "Confirm your new JobStar email address", firstName, body)
+	if err != nil {
+		// Without the link the change can never be confirmed
+		if cancelErr := models.CancelEmailChange(userIdStr); cancelErr != nil {
+			log.Printf("Error cancelling email change for user %s: %v", userIdStr, cancelErr)
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to send email", err)
+		return
+	}
+
+	notice := fmt.Sprintf(`
+		<p>We received a request to change the email address of your JobStar account to %s.</p>
+		<p>Nothing changes until the request is confirmed from that address.</p>
+		<p>If this was not you, log in to cancel the request and change your password.</p>
+	`, html.EscapeString(request.NewEmail))
+
+	err = email.SendEmail(currentEmail, "Your JobStar email address is being changed", firstName, notice)
+	if err != nil {
+		// The request stands; the notice is a courtesy
+		log.Printf("Error sending email change notice to user %s: %v", userIdStr, err)
+	}
+
+	utils.RespondJSON(c, http.StatusAccepted, "Check your new email address for a confirmation link", gin.H{
+		"pendingEmail": request.NewEmail,
+		"expiresAt":    expiresAt,
+	})
+}
+
+// @Summary Confirm email address change
+// @Description Applies a pending email address change using the link sent to the new address. No login required.
+// @Tags Auth
+// @Produce  json
+// @Param t query string true "Confirmation Token"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /auth/email/confirm [GET]
+func ConfirmEmailChange(c *gin.Context) {
+	token := c.Query("t")
+
+	if token == "" {
+		utils.RespondError(c, http.StatusBadRequest, "Token is required", nil)
+		return
+	}
+
+	change, err := models.ConfirmEmailChange(token)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidEmailChangeToken):
+			utils.RespondError(c, http.StatusBadRequest, "Unable to change email address", err)
+		case errors.Is(err, models.ErrEmailTaken):
+			utils.RespondError(c, http.StatusConflict, "That email address has been taken by another account since the change was requested", err)
+		default:
+			utils.RespondError(c, http.StatusInternalServerError, "Unable to change email address", err)
+		}
+		return
+	}
+
+	notice := fmt.Sprintf(`
+		<p>The email address of your JobStar account has been changed to %s.</p>
+		<p>If this was not you, please contact us straight away.</p>
+	`, html.EscapeString(change.NewEmail))
+
+	err = email.SendEmail(change.OldEmail, "Your JobStar email address has been changed", change.FirstName, notice)
+	if err != nil {
+		log.Printf("Error sending email changed notice to user %s: %v", change.UserID, err)
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Email address changed, log in with your new address", gin.H{
+		"email": change.NewEmail,
+	})
+}
+
+// @Summary Cancel email address change
+// @Description Drops the authenticated user's pending email address change, so its confirmation link stops working
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/email [DELETE]
+func CancelEmailChange(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	err := models.CancelEmailChange(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not cancel email address change", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Email address change cancelled", nil)
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+// @Summary Get exchange rates
+// @Description Lists the exchange rates salaries are converted with, as units of each currency per US dollar
+// @Tags Exchange Rate
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /exchange-rates [GET]
+func GetExchangeRates(c *gin.Context) {
+	rates, err := models.GetExchangeRates()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch exchange rates", err)
+		return
+	}
+
+	if rates == nil {
+		rates = []models.ExchangeRate{}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"base":  models.BaseCurrency,
+		"rates": rates,
+	})
+}
+
+// @Summary Update exchange rates
+// @Description Sets the rates of the currencies given, as units per US dollar, adding any that are new. Other currencies keep their rates. Admins only.
+// @Tags Exchange Rate
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param rates body models.ExchangeRateUpdate true "Rates by currency"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /exchange-rates [PATCH]
+func UpdateExchangeRates(c *gin.Context) {
+	var update models.ExchangeRateUpdate
+	err := c.ShouldBindJSON(&update)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	err = update.Validate()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid exchange rates", err)
+		return
+	}
+
+	err = update.Save()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not update exchange rates", err)
+		return
+	}
+
+	rates, err := models.GetExchangeRates()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch exchange rates", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Exchange rates updated successfully", gin.H{
+		"base":  models.BaseCurrency,
+		"rates": rates,
+	})
+}
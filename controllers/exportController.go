@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/exporters"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+func attachmentHeader(fileName string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": fileName})
+}
+
+// @Summary Export jobs
+// @Description Downloads the authenticated user's jobs as CSV, JSON or XLSX. Takes the same filters as listing jobs. The file is streamed as it is written.
+// @Tags Job
+// @Security ApiKeyAuth
+// @Produce  octet-stream
+// @Param   format   query    string  false  "csv (default), json or xlsx"
+// @Param   status   query    string  false  "Status"
+// @Param   jobType   query    string  false  "Job type"
+// @Param   workMode   query    string  false  "onsite, hybrid or remote"
+// @Param   source   query    string  false  "linkedin, referral, company_site, job_board, recruiter or other"
+// @Param   minPriority   query    int  false  "Minimum priority (1-5)"
+// @Param   minSalary   query    int  false  "Only jobs whose salary range reaches this amount"
+// @Param   currency   query    string  false  "Salary currency (ISO 4217)"
+// @Param   deadlineAfter   query    string  false  "Deadline on or after (YYYY-MM-DD)"
+// @Param   deadlineBefore   query    string  false  "Deadline on or before (YYYY-MM-DD)"
+// @Param   search   query    string  false  "Matches company or position"
+// @Param   tags   query    string  false  "Comma-separated tag names"
+// @Param   tagMatch   query    string  false  "any (default) or all of the tags"
+// @Param   archived   query    string  false  "false (default) hides archived jobs, true shows only archived jobs, all shows both"
+// @Param   near   query    string  false  "City to search around, such as Berlin or Portland, ME"
+// @Param   radiusKm   query    number  false  "Distance from near in kilometres (default 50)"
+// @Param   sort   query    string  false  "newest (default), oldest, deadline, priority or company"
+// @Success 200 {file} file "Exported jobs"
+// @Failure 400 {object} models.ErrorResponse
+// @Router /jobs/export [GET]
+func ExportJobs(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if !exporters.FormatIsValid(format) {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid format, expected csv, json or xlsx", nil)
+		return
+	}
+
+	filter, err := parseJobFilter(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+
+	fileName := "jobs-" + time.Now().UTC().Format("2006-01-02") + "." + format
+	c.Header("Content-Type", exporters.ContentType(format))
+	c.Header("Content-Disposition", attachmentHeader(fileName))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	writer, err := exporters.New(format, c.Writer)
+	if err == nil {
+		err = models.StreamJobs(userIdStr, filter, writer.Write)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// The status line has already gone out, so all we can do is cut the
+		// download short
+		log.Printf("Error exporting jobs: %v", err)
+		c.Abort()
+	}
+}
+
+// @Summary Export account data
+// @Description Downloads everything held for the authenticated user as a zip archive: profile, jobs, notes, status history, interviews, contacts, tags and uploaded documents
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  application/zip
+// @Success 200 {file} file "Account archive"
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/export [GET]
+func ExportAccount(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	// Fail before the download starts if the account cannot be read at all
+	_, err := models.GetProfile(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch user data", err)
+		return
+	}
+
+	fileName := "jobstar-export-" + time.Now().UTC().Format("2006-01-02") + ".zip"
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", attachmentHeader(fileName))
+	c.Status(http.StatusOK)
+
+	err = exporters.WriteAccountArchive(c.Request.Context(), c.Writer, userIdStr)
+	if err != nil {
+		log.Printf("Error exporting account: %v", err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/importers"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+const (
+	defaultImportMaxBytes = 5 << 20 // 5 MB
+	defaultImportMaxRows  = 1000
+)
+
+// importFormat takes an explicit format parameter, or else works out whether
+// the upload is CSV or JSON from the file extension, then the content type.
+func importFormat(format, fileName, contentType string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return "csv"
+	case ".json":
+		return "json"
+	}
+
+	switch {
+	case strings.Contains(contentType, "csv"):
+		return "csv"
+	case strings.Contains(contentType, "json"):
+		return "json"
+	}
+
+	return ""
+}
+
+// @Summary Import jobs from CSV, JSON or another job tracker
+// @Description Imports jobs from a CSV file with a header row, a JSON array of objects, or an export from Huntr, Teal or LinkedIn ("Job Applications.csv"), sent either as a multipart "file" or as the raw request body. Generic CSV and JSON columns are matched to job fields by name (ignoring case, spaces, underscores and dashes) unless mapped explicitly; tracker stages are translated to JobStar statuses. Each row is validated with the same rules as creating a job, and rows matching an existing job by company, position and date are skipped, as are rows that look like a job added around the same time unless force is set. Valid rows are inserted together in one transaction and the response reports on every row. With dryRun nothing is saved.
+// @Tags Job
+// @Security ApiKeyAuth
+// @Accept  multipart/form-data,text/csv,application/json
+// @Produce  json
+// @Param   file   formData    file  false  "File to import"
+// @Param   format   query    string  false  "csv, json, huntr, teal or linkedin. csv and json can be told from the file name or content type"
+// @Param   mapping   query    string  false  "csv and json only: JSON object mapping source column headers to job fields, e.g. {\"Company Name\":\"company\"}"
+// @Param   dryRun   query    bool  false  "Validate only, do not save"
+// @Param   force   query    bool  false  "Import rows even if they look like jobs already added"
+// @Param   Idempotency-Key   header    string  false  "Unique key for this request; retries with the same key get the first response back instead of importing again"
+// @Success 200 {object} models.SuccessResponse
+// @Success 201 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 422 {object} models.SuccessResponse
+// @Router /jobs/import [POST]
+func ImportJobs(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	maxBytes := utils.EnvInt("IMPORT_MAX_BYTES", defaultImportMaxBytes)
+	maxRows := int(utils.EnvInt("IMPORT_MAX_ROWS", defaultImportMaxRows))
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+
+	mapping := importers.Mapping{}
+	if raw := c.DefaultPostForm("mapping", c.Query("mapping")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "Invalid mapping", err)
+			return
+		}
+		if err := mapping.Validate(); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "Invalid mapping", err)
+			return
+		}
+	}
+
+	dryRun := c.DefaultPostForm("dryRun", c.Query("dryRun")) == "true"
+	force := c.DefaultPostForm("force", c.Query("force")) == "true"
+	format := c.DefaultPostForm("format", c.Query("format"))
+
+	var body io.Reader
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				utils.RespondError(c, http.StatusRequestEntityTooLarge, "File is too large", nil)
+				return
+			}
+			utils.RespondError(c, http.StatusBadRequest, "Please provide a file", err)
+			return
+		}
+		if fileHeader.Size > maxBytes {
+			utils.RespondError(c, http.StatusRequestEntityTooLarge, "File is too large", nil)
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "Could not read file", err)
+			return
+		}
+		defer file.Close()
+
+		body = file
+		format = importFormat(format, fileHeader.Filename, fileHeader.Header.Get("Content-Type"))
+	} else {
+		body = c.Request.Body
+		format = importFormat(format, "", c.ContentType())
+	}
+
+	if format == "" {
+		utils.RespondError(c, http.StatusBadRequest, "Unknown import format, expected one of "+strings.Join(importers.Formats(), ", "), nil)
+		return
+	}
+
+	importer, err := importers.New(format, mapping)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid import format", err)
+		return
+	}
+
+	records, err := importer.Parse(body, maxRows)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.RespondError(c, http.StatusRequestEntityTooLarge, "File is too large", nil)
+			return
+		}
+		utils.RespondError(c, http.StatusBadRequest, "Could not parse file", err)
+		return
+	}
+
+	if len(records) == 0 {
+		utils.RespondError(c, http.StatusBadRequest, "File has no rows", nil)
+		return
+	}
+
+	existing, err := models.GetJobKeys(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not check for duplicates", err)
+		return
+	}
+
+	var finder *models.DuplicateFinder
+	if !force {
+		finder, err = newDuplicateFinder(userIdStr)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "Could not check for duplicates", err)
+			return
+		}
+	}
+
+	jobs, report := importers.Prepare(records, userIdStr, existing, finder)
+	report.DryRun = dryRun
+
+	if dryRun {
+		utils.RespondJSON(c, http.StatusOK, "Import checked, nothing was saved", gin.H{
+			"report": report,
+		})
+		return
+	}
+
+	if len(jobs) == 0 {
+		utils.RespondJSON(c, http.StatusUnprocessableEntity, "No valid rows to import", gin.H{
+			"report": report,
+		})
+		return
+	}
+
+	err = models.ImportJobs(jobs)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not import jobs", err)
+		return
+	}
+	report.MarkImported(jobs)
+
+	utils.RespondJSON(c, http.StatusCreated, "Jobs imported successfully", gin.H{
+		"report": report,
+	})
+}
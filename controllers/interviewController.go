@@ -0,0 +1,467 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+// @Summary Schedule an interview for a job
+// @Description Adds an interview to one of the authenticated user's jobs
+// @Tags Interview
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param interview body models.InterviewRequest true "Interview Data"
+// @Success 201 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/interviews [POST]
+func CreateInterview(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	var request models.InterviewRequest
+	err = c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	interview, err := request.ToInterview()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid interview data", err)
+		return
+	}
+
+	interview.JobID = jobId
+
+	err = interview.Save()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not create interview", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusCreated, "Interview created successfully", gin.H{
+		"interview": interview,
+	})
+}
+
+// @Summary Get interviews for a job
+// @Description Lists the interviews scheduled for a job, earliest first
+// @Tags Interview
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/interviews [GET]
+func GetJobInterviews(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	interviews, err := models.GetJobInterviews(jobId)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch interviews", err)
+		return
+	}
+
+	if interviews == nil {
+		interviews = []models.JobInterview{}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"interviews": interviews,
+	})
+}
+
+// @Summary Get an interview
+// @Description Retrieves a single interview of a job
+// @Tags Interview
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param   interviewId   path    string  true  "Interview ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/interviews/{interviewId} [GET]
+func GetSingleInterview(c *gin.Context) {
+	jobId := c.Param("id")
+	interviewId := c.Param("interviewId")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	interview, err := models.GetJobInterview(jobId, interviewId)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch interview", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"interview": interview,
+	})
+}
+
+// @Summary Update an interview
+// @Description Reschedules an interview or records its outcome
+// @Tags Interview
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param   interviewId   path    string  true  "Interview ID"
+// @Param interview body models.InterviewRequest true "Interview Data"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/interviews/{interviewId} [PATCH]
+func UpdateInterview(c *gin.Context) {
+	jobId := c.Param("id")
+	interviewId := c.Param("interviewId")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	var request models.InterviewRequest
+	err = c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	interview, err := request.ToInterview()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid interview data", err)
+		return
+	}
+
+	interview.ID = interviewId
+	interview.JobID = jobId
+
+	err = interview.Update()
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Could not update interview", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Interview updated successfully", nil)
+}
+
+// @Summary Delete an interview
+// @Description Removes an interview from a job
+// @Tags Interview
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param   interviewId   path    string  true  "Interview ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/interviews/{interviewId} [DELETE]
+func DeleteInterview(c *gin.Context) {
+	jobId := c.Param("id")
+	interviewId := c.Param("interviewId")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	interview, err := models.GetJobInterview(jobId, interviewId)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch interview", err)
+		return
+	}
+
+	err = interview.Delete()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not delete interview", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Interview deleted successfully", nil)
+}
+
+// @Summary Download an interview as an .ics file
+// @Description Returns a single interview as an iCalendar file that can be imported into Google Calendar or Outlook
+// @Tags Interview
+// @Security ApiKeyAuth
+// @Produce  text/calendar
+// @Param   id   path    string  true  "Job ID"
+// @Param   interviewId   path    string  true  "Interview ID"
+// @Success 200 {string} string "iCalendar file"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/interviews/{interviewId}/ics [GET]
+func DownloadInterviewICS(c *gin.Context) {
+	jobId := c.Param("id")
+	interviewId := c.Param("interviewId")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	job, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	interview, err := models.GetJobInterview(jobId, interviewId)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch interview", err)
+		return
+	}
+
+	interview.Company = job.Company
+	interview.Position = job.Position
+
+	calendar := utils.BuildICS("JobStar interview", []utils.ICSEvent{interviewEvent(*interview)}, time.Now())
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="interview-%s.ics"`, interview.ID))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(calendar))
+}
+
+// @Summary Get the interview calendar feed URL
+// @Description Returns the secret URL of the user's interview calendar feed, for subscribing from Google Calendar or Outlook
+// @Tags Interview
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/calendar [GET]
+func GetCalendarFeed(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	token, err := models.GetCalendarToken(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch calendar feed", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"feedUrl": calendarFeedURL(token),
+	})
+}
+
+// @Summary Reset the interview calendar feed URL
+// @Description Generates a new secret feed URL. The previous URL stops working immediately.
+// @Tags Interview
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/calendar/reset [POST]
+func ResetCalendarFeed(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	token, err := models.ResetCalendarToken(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to reset calendar feed", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Calendar feed reset successfully", gin.H{
+		"feedUrl": calendarFeedURL(token),
+	})
+}
+
+// @Summary Interview calendar feed
+// @Description iCalendar feed of all of a user's interviews. The secret token in the URL is the only credential.
+// @Tags Interview
+// @Produce  text/calendar
+// @Param   token   path    string  true  "Calendar Token"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /calendar/{token}/interviews.ics [GET]
+func CalendarFeed(c *gin.Context) {
+	token := c.Param("token")
+
+	userId, err := models.GetUserIdByCalendarToken(token)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Calendar not found", err)
+		return
+	}
+
+	interviews, err := models.GetUserInterviews(userId, false)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch interviews", err)
+		return
+	}
+
+	events := make([]utils.ICSEvent, 0, len(interviews))
+	for _, interview := range interviews {
+		events = append(events, interviewEvent(interview))
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(utils.BuildICS("JobStar interviews", events, time.Now())))
+}
+
+func calendarFeedURL(token string) string {
+	return fmt.Sprintf("%s/api/v1/calendar/%s/interviews.ics", os.Getenv("APIHostURL"), token)
+}
+
+func interviewEvent(interview models.JobInterview) utils.ICSEvent {
+	summary := fmt.Sprintf("Interview: %s at %s", interview.Position, interview.Company)
+	if interview.RoundName != "" {
+		summary = fmt.Sprintf("%s (%s)", summary, interview.RoundName)
+	}
+	if interview.Outcome == models.OutcomeCancelled {
+		summary = "CANCELLED - " + summary
+	}
+
+	var description []string
+	if interview.Interviewer != "" {
+		description = append(description, "Interviewer: "+interview.Interviewer)
+	}
+	if interview.VideoLink != "" {
+		description = append(description, "Video link: "+interview.VideoLink)
+	}
+	description = append(description, "Timezone: "+interview.Timezone)
+
+	location := interview.Location
+	if location == "" {
+		location = interview.VideoLink
+	}
+
+	return utils.ICSEvent{
+		UID:         interview.ID + "@jobstar",
+		Start:       interview.ScheduledAt,
+		End:         interview.EndsAt(),
+		Summary:     summary,
+		Description: strings.Join(description, "\n"),
+		Location:    location,
+		URL:         interview.VideoLink,
+		Updated:     interview.UpdatedAt,
+		Sequence:    interview.Revision,
+	}
+}
@@ -1,22 +1,36 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"jobstar.com/api/geo"
 	"jobstar.com/api/models"
 	"jobstar.com/api/utils"
 )
 
+const defaultJobTrashRetentionDays = 30
+
 // @Summary User creates a Job
-// @Description User creates a Job
+// @Description User creates a Job. If it looks like a job already added recently (similar company and position, ignoring case, punctuation and suffixes like "Inc."), nothing is saved and 409 is returned with the likely duplicates; send force=true to add it anyway.
 // @Tags job
 // @Accept  json
 // @Security ApiKeyAuth
 // @Produce  json
 // @Param user body models.JobRequest true "Create Job Data"
+// @Param   force   query    bool  false  "Add the job even if it looks like a duplicate"
+// @Param   Idempotency-Key   header    string  false  "Unique key for this request; retries with the same key get the first response back instead of creating another job"
 // @Success 201 {object} models.SuccessResponse
+// @Failure 409 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /jobs [POST]
@@ -46,55 +60,173 @@ func CreateJob(c *gin.Context) {
 		return
 	}
 
-	if job.Company == "" {
-		utils.RespondError(c, http.StatusBadRequest, "Please provide company name", nil)
+	err = job.Validate()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid job data", err)
 		return
 	}
-	if job.JobLocation == "" {
-		utils.RespondError(c, http.StatusBadRequest, "Please provide job location", nil)
-		return
+
+	job.CreatedAt = time.Now()
+	job.CreatedBy = userIdStr
+
+	if c.Query("force") != "true" {
+		finder, err := newDuplicateFinder(userIdStr)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "Could not check for duplicates", err)
+			return
+		}
+
+		if duplicates := finder.Find(job.Company, job.Position, job.CreatedAt); len(duplicates) > 0 {
+			utils.RespondJSON(c, http.StatusConflict, "This looks like a job you have already added, send force=true to add it anyway", gin.H{
+				"duplicates": duplicates,
+			})
+			return
+		}
 	}
-	if job.Position == "" {
-		utils.RespondError(c, http.StatusBadRequest, "Please provide position", nil)
+
+	err = job.SaveJob()
+
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not create job", nil)
 		return
 	}
-	if job.JobType == "" {
-		utils.RespondError(c, http.StatusBadRequest, "Please provide job type", nil)
-		return
+	utils.RespondJSON(c, http.StatusOK, "Job created successfully", gin.H{
+		"job": job,
+	})
+
+}
+
+// parseJobFilter reads the job listing filters from the query string.
+func parseJobFilter(c *gin.Context) (models.JobFilter, error) {
+	return parseJobFilterValues(c.Request.URL.Query())
+}
+
+// parseJobFilterValues reads the job listing filters from query parameters,
+// wherever they came from.
+func parseJobFilterValues(query url.Values) (models.JobFilter, error) {
+	filter := models.JobFilter{
+		Status:         models.Status(query.Get("status")),
+		JobType:        models.JobType(query.Get("jobType")),
+		WorkMode:       models.WorkMode(query.Get("workMode")),
+		Source:         models.JobSource(query.Get("source")),
+		SalaryCurrency: strings.ToUpper(query.Get("currency")),
+		Search:         strings.TrimSpace(query.Get("search")),
+		TagMatch:       query.Get("tagMatch"),
+		Sort:           query.Get("sort"),
+		Archived:       query.Get("archived"),
 	}
 
-	if !job.JobType.JobTypeIsValid() {
-		utils.RespondError(c, http.StatusBadRequest, "Invalid job type", nil)
-		return
+	for _, tag := range strings.Split(query.Get("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
 	}
 
-	if job.Status == "" {
-		job.Status = "pending"
+	if filter.Status != "" && !filter.Status.StatusIsValid() {
+		return filter, errors.New("invalid status")
+	}
+	if filter.JobType != "" && !filter.JobType.JobTypeIsValid() {
+		return filter, errors.New("invalid jobType")
+	}
+	if filter.WorkMode != "" && !filter.WorkMode.WorkModeIsValid() {
+		return filter, errors.New("invalid workMode")
+	}
+	if filter.Source != "" && !filter.Source.SourceIsValid() {
+		return filter, errors.New("invalid source")
+	}
+	if !filter.SortIsValid() {
+		return filter, errors.New("invalid sort")
+	}
+	if !filter.TagMatchIsValid() {
+		return filter, errors.New("invalid tagMatch")
+	}
+	if !filter.ArchivedIsValid() {
+		return filter, errors.New("invalid archived, expected false, true or all")
 	}
 
-	if !job.Status.StatusIsValid() {
-		utils.RespondError(c, http.StatusBadRequest, "Invalid Status", nil)
-		return
+	if value := query.Get("minPriority"); value != "" {
+		priority, err := strconv.Atoi(value)
+		if err != nil || priority < models.MinPriority || priority > models.MaxPriority {
+			return filter, errors.New("invalid minPriority")
+		}
+		filter.MinPriority = priority
 	}
 
-	job.CreatedAt = time.Now()
-	job.CreatedBy = userIdStr
+	if value := query.Get("minSalary"); value != "" {
+		salary, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || salary < 0 {
+			return filter, errors.New("invalid minSalary")
+		}
+		filter.MinSalary = salary
+	}
 
-	err = job.SaveJob()
+	if near := strings.TrimSpace(query.Get("near")); near != "" {
+		filter.Near = geo.Parse(near)
+		if !filter.Near.HasCoordinates() {
+			return filter, fmt.Errorf("unknown city %q for near", near)
+		}
+
+		filter.RadiusKm = models.DefaultRadiusKm
+		if value := query.Get("radiusKm"); value != "" {
+			radius, err := strconv.ParseFloat(value, 64)
+			if err != nil || math.IsNaN(radius) || radius <= 0 || radius > models.MaxRadiusKm {
+				return filter, errors.New("invalid radiusKm")
+			}
+			filter.RadiusKm = radius
+		}
+	} else if query.Get("radiusKm") != "" {
+		return filter, errors.New("radiusKm needs near")
+	}
 
+	var err error
+	filter.DeadlineAfter, err = parseDateQuery(query, "deadlineAfter")
 	if err != nil {
-		utils.RespondError(c, http.StatusInternalServerError, "Could not create job", nil)
+		return filter, err
+	}
+	filter.DeadlineBefore, err = parseDateQuery(query, "deadlineBefore")
+	if err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
 
+// parseDateQuery reads an optional YYYY-MM-DD query parameter.
+func parseDateQuery(query url.Values, param string) (*models.Date, error) {
+	value := query.Get(param)
+	if value == "" {
+		return nil, nil
 	}
-	utils.RespondJSON(c, http.StatusOK, "Job craeted successfully", nil)
 
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, expected YYYY-MM-DD", param)
+	}
+
+	return &models.Date{Time: date}, nil
 }
 
 // @Summary Get all jobs for user
-// @Description Gets all Jobs created by user
+// @Description Gets all Jobs created by user, optionally filtered and sorted
 // @Tags Job
 // @Security ApiKeyAuth
 // @Produce  json
+// @Param   status   query    string  false  "Status"
+// @Param   jobType   query    string  false  "Job type"
+// @Param   workMode   query    string  false  "onsite, hybrid or remote"
+// @Param   source   query    string  false  "linkedin, referral, company_site, job_board, recruiter or other"
+// @Param   minPriority   query    int  false  "Minimum priority (1-5)"
+// @Param   minSalary   query    int  false  "Only jobs whose salary range reaches this amount"
+// @Param   currency   query    string  false  "Salary currency (ISO 4217)"
+// @Param   deadlineAfter   query    string  false  "Deadline on or after (YYYY-MM-DD)"
+// @Param   deadlineBefore   query    string  false  "Deadline on or before (YYYY-MM-DD)"
+// @Param   search   query    string  false  "Matches company or position"
+// @Param   tags   query    string  false  "Comma-separated tag names"
+// @Param   tagMatch   query    string  false  "any (default) or all of the tags"
+// @Param   archived   query    string  false  "false (default) hides archived jobs, true shows only archived jobs, all shows both"
+// @Param   near   query    string  false  "City to search around, such as Berlin or Portland, ME"
+// @Param   radiusKm   query    number  false  "Distance from near in kilometres (default 50)"
+// @Param   sort   query    string  false  "newest (default), oldest, deadline, priority or company"
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
@@ -114,7 +246,13 @@ func GetJobsByUser(c *gin.Context) {
 		return
 	}
 
-	jobs, err := models.GetJobs(userIdStr)
+	filter, err := parseJobFilter(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+
+	jobs, err := models.GetJobs(userIdStr, filter)
 
 	if err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, "could not fetch Jobs", err)
@@ -126,6 +264,12 @@ func GetJobsByUser(c *gin.Context) {
 		jobs = []models.Job{} // Return an empty slice instead of nil
 	}
 
+	err = models.LoadJobTags(jobs)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "could not fetch Jobs", err)
+		return
+	}
+
 	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
 		"jobs": jobs,
 	})
@@ -138,7 +282,9 @@ func GetJobsByUser(c *gin.Context) {
 // @Security ApiKeyAuth
 // @Produce  json
 // @Param   id   path    string  true  "Job ID"
+// @Param   If-None-Match   header    string  false  "ETag from an earlier response; 304 is returned if the job has not changed"
 // @Success 200 {object} models.SuccessResponse
+// @Success 304 "Not modified"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /jobs/{id} [GET]
@@ -164,20 +310,38 @@ func GetSingleJob(c *gin.Context) {
 		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch job data", err)
 		return
 	}
+
+	etag := utils.ETag(job.Version)
+	c.Header("ETag", etag)
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && utils.ETagMatches(ifNoneMatch, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	jobs := []models.Job{*job}
+	err = models.LoadJobTags(jobs)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch job data", err)
+		return
+	}
+	job = &jobs[0]
+
 	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
 		"job": job,
 	})
 }
 
 // @Summary Get job by ID
-// @Description Deletes a job using its ID
+// @Description Moves a job to the trash. It can be restored with POST /jobs/{id}/restore until the undoUntil time in the response, after which it is permanently deleted along with its documents.
 // @Tags Job
 // @Security ApiKeyAuth
 // @Produce  json
 // @Param   id   path    string  true  "Job ID"
+// @Param   If-Match   header    string  false  "ETag the deletion is based on; 412 is returned if the job has changed since"
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
 // @Router /jobs/{id} [DELETE]
 func DeleteJob(c *gin.Context) {
 	jobId := c.Param("id")
@@ -197,6 +361,10 @@ func DeleteJob(c *gin.Context) {
 	job, err := models.GetJobById(jobId)
 
 	if err != nil {
+		if errors.Is(err, models.ErrJobNotFound) {
+			utils.RespondError(c, http.StatusNotFound, "Job not found", err)
+			return
+		}
 		utils.RespondError(c, http.StatusInternalServerError, "could not fecth Job", err)
 		return
 	}
@@ -206,108 +374,303 @@ func DeleteJob(c *gin.Context) {
 		return
 	}
 
-	err = job.Delete()
+	expectedVersion, ok := checkIfMatch(c, job)
+	if !ok {
+		return
+	}
+
+	err = job.Delete(expectedVersion)
 	if err != nil {
-		utils.RespondError(c, http.StatusInternalServerError, "Could not delete Job", nil)
+		switch {
+		case errors.Is(err, models.ErrVersionConflict):
+			respondVersionConflict(c, err)
+		case errors.Is(err, models.ErrJobNotFound):
+			utils.RespondError(c, http.StatusNotFound, "Job not found", err)
+		default:
+			utils.RespondError(c, http.StatusInternalServerError, "Could not delete Job", nil)
+		}
 		return
 	}
 
-	utils.RespondJSON(c, http.StatusOK, "Job Deleted successfully", nil)
+	retentionDays := utils.EnvInt("JOB_TRASH_RETENTION_DAYS", defaultJobTrashRetentionDays)
+	utils.RespondJSON(c, http.StatusOK, "Job moved to trash", gin.H{
+		"undoUntil": job.DeletedAt.AddDate(0, 0, int(retentionDays)),
+	})
+}
+
+// @Summary Get deleted jobs
+// @Description Lists the authenticated user's jobs in the trash, most recently deleted first. Each can be restored until it is purged, which happens once it has been in the trash for the retention period (30 days by default).
+// @Tags Job
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /jobs/trash [GET]
+func GetTrashedJobs(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	jobs, err := models.GetTrashedJobs(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch deleted jobs", err)
+		return
+	}
+
+	err = models.LoadJobTags(jobs)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch deleted jobs", err)
+		return
+	}
+
+	retentionDays := int(utils.EnvInt("JOB_TRASH_RETENTION_DAYS", defaultJobTrashRetentionDays))
+	trash := make([]gin.H, len(jobs))
+	for i, job := range jobs {
+		trash[i] = gin.H{
+			"job":       job,
+			"undoUntil": job.DeletedAt.AddDate(0, 0, retentionDays),
+		}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"jobs": trash,
+	})
+}
+
+// @Summary Restore a deleted job
+// @Description Takes a job back out of the trash. This is only possible until the undoUntil time given when it was deleted.
+// @Tags Job
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/restore [POST]
+func RestoreJob(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	retentionDays := int(utils.EnvInt("JOB_TRASH_RETENTION_DAYS", defaultJobTrashRetentionDays))
+	job, err := models.RestoreJob(jobId, userIdStr, time.Now().AddDate(0, 0, -retentionDays))
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to restore job", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Job restored successfully", gin.H{
+		"job": job,
+	})
 }
 
 // @Summary Update Job details
-// @Description Updates the authenticated user's details
+// @Description Changes only the fields supplied. The body is an RFC 7396 merge patch (Content-Type application/merge-patch+json, or application/json), where null clears a field, or an RFC 6902 JSON Patch (Content-Type application/json-patch+json). The patched job is validated as a whole before anything is saved. Tags are managed through /jobs/{id}/tags and ignored here.
 // @Tags Job
 // @Security ApiKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param   id   path    string  true  "Job ID"
-// @Param user body models.JobRequest true "User UpdateJob Data"
+// @Param user body models.JobRequest true "Fields to change"
+// @Param   If-Match   header    string  false  "ETag the update is based on; 412 is returned if the job has changed since"
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 415 {object} models.ErrorResponse
 // @Router /jobs/{id} [PATCH]
 func UpdateJob(c *gin.Context) {
-
-	var updatedJob models.Job
-	err := c.ShouldBindJSON(&updatedJob)
-
-	if err != nil {
-		utils.RespondError(c, http.StatusBadRequest, "Could not parse request data", err)
+	job, expectedVersion, ok := loadJobForWrite(c)
+	if !ok {
+		return
 	}
 
-	if updatedJob.Company == "" {
-		utils.RespondError(c, http.StatusBadRequest, "Please provide company name", nil)
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Could not read request data", err)
 		return
 	}
-	if updatedJob.JobLocation == "" {
-		utils.RespondError(c, http.StatusBadRequest, "Please provide job location", nil)
+
+	current, err := json.Marshal(job)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not update Job", err)
 		return
 	}
-	if updatedJob.Position == "" {
-		utils.RespondError(c, http.StatusBadRequest, "Please provide position", nil)
+
+	patched, err := utils.ApplyPatch(c.ContentType(), current, patch)
+	if err != nil {
+		if errors.Is(err, utils.ErrUnsupportedPatchType) {
+			utils.RespondError(c, http.StatusUnsupportedMediaType, "Unsupported patch format", err)
+			return
+		}
+		utils.RespondError(c, http.StatusBadRequest, "Could not apply patch", err)
 		return
 	}
-	if updatedJob.JobType == "" {
-		utils.RespondError(c, http.StatusBadRequest, "Please provide job type", nil)
+
+	var updatedJob models.Job
+	err = json.Unmarshal(patched, &updatedJob)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid job data", err)
 		return
 	}
 
-	if !updatedJob.JobType.JobTypeIsValid() {
-		utils.RespondError(c, http.StatusBadRequest, "Invalid job type", nil)
+	saveJobUpdate(c, job.ID, &updatedJob, expectedVersion)
+}
+
+// @Summary Replace Job details
+// @Description Replaces every editable field of the job; omitted fields are cleared and an omitted status becomes pending
+// @Tags Job
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param user body models.JobRequest true "Job data"
+// @Param   If-Match   header    string  false  "ETag the update is based on; 412 is returned if the job has changed since"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Router /jobs/{id} [PUT]
+func ReplaceJob(c *gin.Context) {
+	job, expectedVersion, ok := loadJobForWrite(c)
+	if !ok {
 		return
 	}
 
-	if updatedJob.Status == "" {
-		updatedJob.Status = "pending"
-	}
+	var updatedJob models.Job
+	err := c.ShouldBindJSON(&updatedJob)
 
-	if !updatedJob.Status.StatusIsValid() {
-		utils.RespondError(c, http.StatusBadRequest, "Invalid Status", nil)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Could not parse request data", err)
 		return
 	}
 
+	saveJobUpdate(c, job.ID, &updatedJob, expectedVersion)
+}
+
+// loadJobForWrite fetches the job being changed, checking it belongs to the
+// user and honouring If-Match. It responds itself and returns false when the
+// write should not go ahead.
+func loadJobForWrite(c *gin.Context) (*models.Job, int, bool) {
 	jobId := c.Param("id")
 	userId, exists := c.Get("userId")
 	if !exists {
 		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist in context", nil)
-		return
+		return nil, 0, false
 	}
 
 	// Type assertion for userId
 	userIdStr, ok := userId.(string)
 	if !ok {
 		utils.RespondError(c, http.StatusUnauthorized, "user ID is not a string", nil)
-		return
+		return nil, 0, false
 	}
 
 	job, err := models.GetJobById(jobId)
 
 	if err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, "could not fecth Job", err)
-		return
+		return nil, 0, false
 	}
 
 	if job.CreatedBy != userIdStr {
 		utils.RespondError(c, http.StatusUnauthorized, "You are not authorized to update this event", nil)
+		return nil, 0, false
+	}
+
+	expectedVersion, ok := checkIfMatch(c, job)
+	if !ok {
+		return nil, 0, false
+	}
+
+	return job, expectedVersion, true
+}
+
+// saveJobUpdate validates the job's new state and writes it.
+func saveJobUpdate(c *gin.Context, jobId string, updatedJob *models.Job, expectedVersion int) {
+	err := updatedJob.Validate()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid job data", err)
 		return
 	}
 
-	err = updatedJob.Update(jobId)
+	err = updatedJob.Update(jobId, expectedVersion)
 
 	if err != nil {
+		if errors.Is(err, models.ErrVersionConflict) {
+			respondVersionConflict(c, err)
+			return
+		}
 		utils.RespondError(c, http.StatusInternalServerError, "Could not update Job", err)
 		return
 	}
 
-	utils.RespondJSON(c, http.StatusOK, "Job Updated successfully", nil)
+	job, err := models.GetJobById(jobId)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch job data", err)
+		return
+	}
+
+	c.Header("ETag", utils.ETag(job.Version))
+
+	utils.RespondJSON(c, http.StatusOK, "Job Updated successfully", gin.H{
+		"job": job,
+	})
+}
+
+// checkIfMatch enforces the If-Match header against the job as read. It
+// returns the version the write must still find, or 0 when the client did
+// not ask for a check. When the job has already moved on it responds 412 and
+// returns false.
+func checkIfMatch(c *gin.Context, job *models.Job) (int, bool) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, true
+	}
+
+	if !utils.ETagMatches(ifMatch, utils.ETag(job.Version)) {
+		respondVersionConflict(c, models.ErrVersionConflict)
+		return 0, false
+	}
+
+	return job.Version, true
+}
+
+func respondVersionConflict(c *gin.Context, err error) {
+	utils.RespondError(c, http.StatusPreconditionFailed, "The job has changed since you last fetched it, reload and try again", err)
 }
 
 // @Summary Shows stats all jobs for user
-// @Description Shows stats all jobs for user
+// @Description Shows status counts, applications per period, tag and country breakdowns, and how your jobs measure up to the target roles and desired salary in your preferences. Applications are counted per day, week (from Monday), month or quarter of the timezone given, with empty periods included; monthlyApplications always counts the same range per month, for older clients. from and to also limit the status counts; without them the counts cover every job and the periods end today.
 // @Tags Job
 // @Security ApiKeyAuth
 // @Produce  json
+// @Param   from   query    string  false  "First day to count (YYYY-MM-DD)"
+// @Param   to   query    string  false  "Last day to count (YYYY-MM-DD), defaults to today"
+// @Param   granularity   query    string  false  "day, week, month (default) or quarter"
+// @Param   tz   query    string  false  "IANA timezone such as Europe/Berlin, defaults to your timezone"
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
@@ -326,31 +689,131 @@ func ShowStats(c *gin.Context) {
 		return
 	}
 
-	acceptedJobs, pendingJobs, declinedJobs, interviewJobs, err := models.CountStatusJobs(userIdStr)
+	preferences, err := models.GetPreferences(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch user data", err)
+		return
+	}
 
+	statsRange, explicit, err := parseStatsRange(c, preferences.Timezone)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid stats range", err)
+		return
+	}
+
+	var countRange *models.StatsRange
+	if explicit {
+		countRange = &statsRange
+	}
+	counts, err := models.GetStatusCounts(userIdStr, countRange)
 	if err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, "unable to count data", err)
 		return
 	}
 	defaultStats := map[string]int{
-		"accepted":  acceptedJobs,
-		"pending":   pendingJobs,
-		"interview": interviewJobs,
-		"declined":  declinedJobs,
+		"accepted":  counts[models.Accepted],
+		"pending":   counts[models.Pending],
+		"interview": counts[models.Interview],
+		"declined":  counts[models.Declined],
 	}
 
-	monthlyApplications, err := models.GetMonthlyApplications(userIdStr)
+	applications, err := models.GetApplicationBuckets(userIdStr, statsRange)
 	if err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, "unable to count data", err)
 		return
 	}
 
-	// Reverse the slice to match the JavaScript implementation
-	for i, j := 0, len(monthlyApplications)-1; i < j; i, j = i+1, j-1 {
-		monthlyApplications[i], monthlyApplications[j] = monthlyApplications[j], monthlyApplications[i]
+	monthBuckets := applications
+	if statsRange.Granularity != models.ByMonth {
+		monthBuckets, err = models.GetApplicationBuckets(userIdStr, statsRange.Monthly())
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "unable to count data", err)
+			return
+		}
+	}
+
+	tagStats, err := models.GetTagStats(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "unable to count data", err)
+		return
+	}
+	if tagStats == nil {
+		tagStats = []models.TagStats{}
 	}
+
+	countryStats, err := models.GetCountryStats(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "unable to count data", err)
+		return
+	}
+	if countryStats == nil {
+		countryStats = []models.CountryStats{}
+	}
+
+	targetRoleStats, err := models.GetTargetRoleStats(userIdStr, preferences.TargetRoles)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "unable to count data", err)
+		return
+	}
+
+	var salaryFit *models.SalaryFit
+	if preferences.DesiredSalary != nil {
+		salaryFit, err = models.GetSalaryFit(userIdStr, *preferences.DesiredSalary)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "unable to count data", err)
+			return
+		}
+	}
+
 	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
 		"defaultStats":        defaultStats,
-		"monthlyApplications": monthlyApplications,
+		"monthlyApplications": models.MonthlyApplications(monthBuckets),
+		"applications":        applications,
+		"range": gin.H{
+			"from":        statsRange.From,
+			"to":          statsRange.To,
+			"granularity": statsRange.Granularity,
+			"timezone":    statsRange.Location.String(),
+		},
+		"tagStats":        tagStats,
+		"countryStats":    countryStats,
+		"targetRoleStats": targetRoleStats,
+		"salaryFit":       salaryFit,
 	})
 }
+
+// parseStatsRange reads the stats range from the query string, using the
+// user's own timezone unless tz is given. It also reports whether from or to
+// was given.
+func parseStatsRange(c *gin.Context, userTimezone string) (models.StatsRange, bool, error) {
+	query := c.Request.URL.Query()
+
+	granularity := models.Granularity(query.Get("granularity"))
+	if granularity == "" {
+		granularity = models.ByMonth
+	}
+	if !granularity.GranularityIsValid() {
+		return models.StatsRange{}, false, errors.New("invalid granularity, expected day, week, month or quarter")
+	}
+
+	timezone := query.Get("tz")
+	if timezone == "" {
+		timezone = userTimezone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return models.StatsRange{}, false, fmt.Errorf("unknown timezone %q", timezone)
+	}
+
+	from, err := parseDateQuery(query, "from")
+	if err != nil {
+		return models.StatsRange{}, false, err
+	}
+	to, err := parseDateQuery(query, "to")
+	if err != nil {
+		return models.StatsRange{}, false, err
+	}
+
+	statsRange, err := models.NewStatsRange(from, to, granularity, location, time.Now())
+	return statsRange, from != nil || to != nil, err
+}
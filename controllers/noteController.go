@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+// @Summary Add a note to a job
+// @Description Attaches a markdown note to one of the authenticated user's jobs
+// @Tags Note
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param note body models.NoteRequest true "Note Data"
+// @Success 201 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/notes [POST]
+func CreateNote(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	var note models.JobNote
+	err = c.ShouldBindJSON(&note)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	if strings.TrimSpace(note.Body) == "" {
+		utils.RespondError(c, http.StatusBadRequest, "Please provide note body", nil)
+		return
+	}
+	if len(note.Body) > models.MaxNoteLength {
+		utils.RespondError(c, http.StatusBadRequest, "Note is too long", nil)
+		return
+	}
+
+	note.JobID = jobId
+
+	err = note.Save()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not create note", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusCreated, "Note created successfully", gin.H{
+		"note": note,
+	})
+}
+
+// @Summary Get notes for a job
+// @Description Lists a job's notes, newest first
+// @Tags Note
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/notes [GET]
+func GetJobNotes(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	notes, err := models.GetJobNotes(jobId)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch notes", err)
+		return
+	}
+
+	if notes == nil {
+		notes = []models.JobNote{}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"notes": notes,
+	})
+}
+
+// @Summary Edit a note
+// @Description Replaces the body of a note
+// @Tags Note
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param   noteId   path    string  true  "Note ID"
+// @Param note body models.NoteRequest true "Note Data"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/notes/{noteId} [PATCH]
+func UpdateNote(c *gin.Context) {
+	jobId := c.Param("id")
+	noteId := c.Param("noteId")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	var note models.JobNote
+	err = c.ShouldBindJSON(&note)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	if strings.TrimSpace(note.Body) == "" {
+		utils.RespondError(c, http.StatusBadRequest, "Please provide note body", nil)
+		return
+	}
+	if len(note.Body) > models.MaxNoteLength {
+		utils.RespondError(c, http.StatusBadRequest, "Note is too long", nil)
+		return
+	}
+
+	note.ID = noteId
+	note.JobID = jobId
+
+	err = note.Update()
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Could not update note", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Note updated successfully", gin.H{
+		"note": note,
+	})
+}
+
+// @Summary Delete a note
+// @Description Removes a note from a job
+// @Tags Note
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param   noteId   path    string  true  "Note ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/notes/{noteId} [DELETE]
+func DeleteNote(c *gin.Context) {
+	jobId := c.Param("id")
+	noteId := c.Param("noteId")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	note, err := models.GetJobNote(jobId, noteId)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch note", err)
+		return
+	}
+
+	err = note.Delete()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not delete note", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Note deleted successfully", nil)
+}
+
+// @Summary Get a job's activity log
+// @Description Returns the job's notes, status changes and interviews as one paginated timeline, newest first
+// @Tags Note
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param   page   query    int  false  "Page number (default 1)"
+// @Param   limit   query    int  false  "Entries per page (default 20, max 100)"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/activity [GET]
+func GetJobActivity(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	pagination := utils.ParsePagination(c)
+
+	activity, total, err := models.GetJobActivity(jobId, pagination.Limit, pagination.Offset())
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch activity", err)
+		return
+	}
+
+	if activity == nil {
+		activity = []models.ActivityEntry{}
+	}
+	pagination.SetTotal(total)
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"activity":   activity,
+		"pagination": pagination,
+	})
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+// @Summary Get your profile
+// @Description Returns the authenticated user's details and preferences
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /auth/me [GET]
+func GetMe(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	profile, err := models.GetProfile(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch user data", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"user": profile,
+	})
+}
+
+// @Summary Get preferences
+// @Description Returns the authenticated user's timezone, locale, preferred currency, notification settings, target roles and desired salary
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /auth/preferences [GET]
+func GetPreferences(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	preferences, err := models.GetPreferences(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch preferences", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"preferences": preferences,
+	})
+}
+
+// @Summary Update preferences
+// @Description Changes only the preferences supplied, given as an RFC 7396 merge patch (Content-Type application/merge-patch+json, or application/json) or an RFC 6902 JSON Patch (Content-Type application/json-patch+json). Set desiredSalary to null to clear it. Currencies must have an exchange rate.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param preferences body models.Preferences true "Preferences to change"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 415 {object} models.ErrorResponse
+// @Router /auth/preferences [PATCH]
+func UpdatePreferences(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	preferences, err := models.GetPreferences(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch preferences", err)
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Could not read request data", err)
+		return
+	}
+
+	current, err := json.Marshal(preferences)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to update preferences", err)
+		return
+	}
+
+	patched, err := utils.ApplyPatch(c.ContentType(), current, patch)
+	if err != nil {
+		if errors.Is(err, utils.ErrUnsupportedPatchType) {
+			utils.RespondError(c, http.StatusUnsupportedMediaType, "Unsupported patch format", err)
+			return
+		}
+		utils.RespondError(c, http.StatusBadRequest, "Could not apply patch", err)
+		return
+	}
+
+	var updated models.Preferences
+	if err := json.Unmarshal(patched, &updated); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid preferences", err)
+		return
+	}
+
+	if err := updated.Validate(); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid preferences", err)
+		return
+	}
+
+	for _, currency := range updated.Currencies() {
+		known, err := models.ExchangeRateExists(currency)
+		if err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "Unable to update preferences", err)
+			return
+		}
+		if !known {
+			utils.RespondError(c, http.StatusBadRequest, "Invalid preferences", fmt.Errorf("no exchange rate for %s", currency))
+			return
+		}
+	}
+
+	if err := updated.Update(userIdStr); err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to update preferences", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Preferences updated successfully", gin.H{
+		"preferences": updated,
+	})
+}
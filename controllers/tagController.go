@@ -0,0 +1,401 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+// @Summary Get tags
+// @Description Lists the authenticated user's tags with the number of jobs carrying each
+// @Tags Tag
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /tags [GET]
+func GetTags(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	tags, err := models.GetUserTags(userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not fetch tags", err)
+		return
+	}
+
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Data retrieved successfully", gin.H{
+		"tags": tags,
+	})
+}
+
+// @Summary Create a tag
+// @Description Creates a tag for the authenticated user. Tag names are unique per user, ignoring case.
+// @Tags Tag
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param tag body models.TagRequest true "Tag Data"
+// @Success 201 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /tags [POST]
+func CreateTag(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var request models.TagRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	name, err := models.NormalizeTagName(request.Name)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid tag name", err)
+		return
+	}
+
+	tag := models.Tag{UserID: userIdStr, Name: name}
+	err = tag.Save()
+	if err != nil {
+		if errors.Is(err, models.ErrTagExists) {
+			utils.RespondError(c, http.StatusConflict, "Tag already exists", err)
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Could not create tag", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusCreated, "Tag created successfully", gin.H{
+		"tag": tag,
+	})
+}
+
+// @Summary Rename a tag
+// @Description Renames one of the authenticated user's tags
+// @Tags Tag
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Tag ID"
+// @Param tag body models.TagRequest true "Tag Data"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /tags/{id} [PATCH]
+func RenameTag(c *gin.Context) {
+	tagId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var request models.TagRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	name, err := models.NormalizeTagName(request.Name)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid tag name", err)
+		return
+	}
+
+	tag, err := models.GetUserTagById(tagId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch tag", err)
+		return
+	}
+
+	err = tag.Rename(name)
+	if err != nil {
+		if errors.Is(err, models.ErrTagExists) {
+			utils.RespondError(c, http.StatusConflict, "A tag with this name already exists, merge the tags instead", err)
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Could not rename tag", err)
+		return
+	}
+	tag.Name = name
+
+	utils.RespondJSON(c, http.StatusOK, "Tag renamed successfully", gin.H{
+		"tag": tag,
+	})
+}
+
+// @Summary Merge a tag into another
+// @Description Moves every job carrying this tag to the target tag, then deletes this tag
+// @Tags Tag
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Tag ID to merge away"
+// @Param merge body models.TagMergeRequest true "Target tag"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /tags/{id}/merge [POST]
+func MergeTag(c *gin.Context) {
+	tagId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var request models.TagMergeRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	if request.TargetID == tagId {
+		utils.RespondError(c, http.StatusBadRequest, "Cannot merge a tag into itself", nil)
+		return
+	}
+
+	source, err := models.GetUserTagById(tagId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch tag", err)
+		return
+	}
+
+	_, err = models.GetUserTagById(request.TargetID, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch target tag", err)
+		return
+	}
+
+	err = source.MergeInto(request.TargetID)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not merge tags", err)
+		return
+	}
+
+	target, err := models.GetUserTagById(request.TargetID, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch target tag", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Tags merged successfully", gin.H{
+		"tag": target,
+	})
+}
+
+// @Summary Delete a tag
+// @Description Deletes a tag and removes it from every job
+// @Tags Tag
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Tag ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /tags/{id} [DELETE]
+func DeleteTag(c *gin.Context) {
+	tagId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	tag, err := models.GetUserTagById(tagId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch tag", err)
+		return
+	}
+
+	err = tag.Delete()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not delete tag", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Tag deleted successfully", nil)
+}
+
+// @Summary Add tags to a job
+// @Description Attaches tags to a job by name, creating any tags the user does not have yet
+// @Tags Tag
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param tags body models.JobTagsRequest true "Tag names"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/tags [POST]
+func AddJobTags(c *gin.Context) {
+	jobId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var request models.JobTagsRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+
+	if len(request.Tags) == 0 {
+		utils.RespondError(c, http.StatusBadRequest, "Please provide at least one tag", nil)
+		return
+	}
+
+	names := make([]string, 0, len(request.Tags))
+	for _, tag := range request.Tags {
+		name, err := models.NormalizeTagName(tag)
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "Invalid tag name", err)
+			return
+		}
+		names = append(names, name)
+	}
+
+	job, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	err = models.AttachTags(jobId, userIdStr, names)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not tag job", err)
+		return
+	}
+
+	jobs := []models.Job{*job}
+	err = models.LoadJobTags(jobs)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Unable to fetch job data", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Tags added successfully", gin.H{
+		"job": jobs[0],
+	})
+}
+
+// @Summary Remove a tag from a job
+// @Description Detaches a tag from a job. The tag itself is kept.
+// @Tags Tag
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param   id   path    string  true  "Job ID"
+// @Param   tagId   path    string  true  "Tag ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /jobs/{id}/tags/{tagId} [DELETE]
+func RemoveJobTag(c *gin.Context) {
+	jobId := c.Param("id")
+	tagId := c.Param("tagId")
+
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	_, err := models.GetUserJobById(jobId, userIdStr)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to fetch job data", err)
+		return
+	}
+
+	err = models.DetachTag(jobId, tagId)
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, "Unable to remove tag", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Tag removed successfully", nil)
+}
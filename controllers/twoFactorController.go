@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+const (
+	defaultPreAuthMinutes = 5
+	totpIssuer            = "JobStar"
+)
+
+// respondTwoFactorRequired ends the password step of a login for a user with
+// two-factor authentication on.
+func respondTwoFactorRequired(c *gin.Context, userId string) {
+	ttl := time.Duration(utils.EnvInt("TWO_FACTOR_PREAUTH_MINUTES", defaultPreAuthMinutes)) * time.Minute
+	preAuthToken, err := utils.GeneratePreAuthToken(userId, ttl)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not authenticate User", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Two-factor code required", gin.H{
+		"twoFactorRequired": true,
+		"preAuthToken":      preAuthToken,
+		"expiresAt":         time.Now().Add(ttl),
+	})
+}
+
+// @Summary Start two-factor setup
+// @Description Creates a new TOTP secret for the authenticated user and returns it with an otpauth:// URI to show as a QR code. Two-factor authentication is only turned on once a code from the app is confirmed with POST /auth/2fa/enable.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /auth/2fa/setup [POST]
+func SetupTwoFactor(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	secret, email, err := models.StartTwoFactorSetup(userIdStr)
+	if err != nil {
+		if errors.Is(err, models.ErrTwoFactorEnabled) {
+			utils.RespondError(c, http.StatusConflict, "Two-factor authentication is already on", err)
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Could not start two-factor setup", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Scan the code with your authenticator app, then confirm a code to turn two-factor authentication on", gin.H{
+		"secret":     secret,
+		"otpauthUri": utils.TOTPURI(totpIssuer, email, secret),
+	})
+}
+
+// @Summary Turn on two-factor authentication
+// @Description Confirms a code from the authenticator app set up with POST /auth/2fa/setup and turns two-factor authentication on. The recovery codes returned are only shown this once; each can be used once in place of a code.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param code body models.TwoFactorEnableRequest true "Code from the authenticator app"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /auth/2fa/enable [POST]
+func EnableTwoFactor(c *gin.Context) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return
+	}
+
+	var request models.TwoFactorEnableRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Please provide a code", err)
+		return
+	}
+
+	codes, err := models.EnableTwoFactor(userIdStr, request.Code, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrTwoFactorEnabled):
+			utils.RespondError(c, http.StatusConflict, "Two-factor authentication is already on", err)
+		case errors.Is(err, models.ErrTwoFactorNotSetUp), errors.Is(err, models.ErrInvalidTwoFactor):
+			utils.RespondError(c, http.StatusBadRequest, "Could not turn on two-factor authentication", err)
+		default:
+			utils.RespondError(c, http.StatusInternalServerError, "Could not turn on two-factor authentication", err)
+		}
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Two-factor authentication turned on, keep your recovery codes somewhere safe", gin.H{
+		"recoveryCodes": codes,
+	})
+}
+
+// @Summary Complete a two-factor login
+// @Description Exchanges the preAuthToken from POST /auth/login and a code from the authenticator app, or an unused recovery code, for a JWT token. After 5 wrong codes in a row verification is locked for 15 minutes.
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param verification body models.TwoFactorVerifyRequest true "Pre-auth token and code"
+// @Success 200 {object} models.AuthResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Router /auth/2fa/verify [POST]
+func VerifyTwoFactor(c *gin.Context) {
+	var request models.TwoFactorVerifyRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Please provide the pre-auth token and a code", err)
+		return
+	}
+
+	err = request.Validate()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Please provide the pre-auth token and a code", err)
+		return
+	}
+
+	userId, err := utils.VerifyPreAuthToken(request.PreAuthToken)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Your login has expired, log in again", err)
+		return
+	}
+
+	email, err := models.VerifyTwoFactor(userId, request.Code, request.RecoveryCode, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidTwoFactor):
+			utils.RespondError(c, http.StatusUnauthorized, "Invalid code", err)
+		case errors.Is(err, models.ErrTwoFactorLocked):
+			utils.RespondError(c, http.StatusTooManyRequests, "Too many invalid codes, try again later", err)
+		default:
+			utils.RespondError(c, http.StatusUnauthorized, "Could not authenticate User", err)
+		}
+		return
+	}
+
+	token, err := utils.GenerateToken(email, userId)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Could not authenticate User", err)
+		return
+	}
+
+	data := gin.H{"token": token}
+	if request.RecoveryCode != "" {
+		remaining, err := models.CountRecoveryCodes(userId)
+		if err == nil {
+			data["recoveryCodesRemaining"] = remaining
+		}
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Login Successful", data)
+}
+
+// @Summary Replace recovery codes
+// @Description Replaces the authenticated user's two-factor recovery codes with a new set, invalidating the old ones. Requires the current password.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param confirmation body models.TwoFactorPasswordRequest true "Current password"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/2fa/recovery-codes [POST]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userIdStr, ok := confirmTwoFactorPassword(c)
+	if !ok {
+		return
+	}
+
+	codes, err := models.RegenerateRecoveryCodes(userIdStr)
+	if err != nil {
+		if errors.Is(err, models.ErrTwoFactorNotEnabled) {
+			utils.RespondError(c, http.StatusBadRequest, "Two-factor authentication is not on", err)
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Could not replace recovery codes", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Recovery codes replaced, keep them somewhere safe", gin.H{
+		"recoveryCodes": codes,
+	})
+}
+
+// @Summary Turn off two-factor authentication
+// @Description Turns off two-factor authentication for the authenticated user and deletes the secret and recovery codes. Requires the current password.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param confirmation body models.TwoFactorPasswordRequest true "Current password"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/2fa [DELETE]
+func DisableTwoFactor(c *gin.Context) {
+	userIdStr, ok := confirmTwoFactorPassword(c)
+	if !ok {
+		return
+	}
+
+	err := models.DisableTwoFactor(userIdStr)
+	if err != nil {
+		if errors.Is(err, models.ErrTwoFactorNotEnabled) {
+			utils.RespondError(c, http.StatusBadRequest, "Two-factor authentication is not on", err)
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Could not turn off two-factor authentication", err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Two-factor authentication turned off", nil)
+}
+
+// confirmTwoFactorPassword reads the authenticated user and checks the
+// password in the request body, responding with an error when either fails.
+func confirmTwoFactorPassword(c *gin.Context) (string, bool) {
+	userId, exists := c.Get("userId")
+
+	if !exists {
+		utils.RespondError(c, http.StatusUnauthorized, "userId does not exist", nil)
+		return "", false
+	}
+
+	// Type assertion for userId
+	userIdStr, ok := userId.(string)
+	if !ok {
+		utils.RespondError(c, http.StatusInternalServerError, "userId is not a string", nil)
+		return "", false
+	}
+
+	var request models.TwoFactorPasswordRequest
+	err := c.ShouldBindJSON(&request)
+
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Please confirm with your password", err)
+		return "", false
+	}
+
+	err = models.CheckPassword(userIdStr, request.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPassword) {
+			utils.RespondError(c, http.StatusUnauthorized, "Password is incorrect", nil)
+			return "", false
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Could not check password", err)
+		return "", false
+	}
+
+	return userIdStr, true
+}
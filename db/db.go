@@ -38,6 +38,7 @@ func InitDB() {
 	DB.SetMaxIdleConns(5)  //no. of connections to be allowed when idle
 
 	createTables()
+	runMigrations()
 }
 
 func createTables() {
@@ -0,0 +1,511 @@
+package db
+
+import (
+	"log"
+)
+
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// migrations are applied in order, once each, after the base tables exist.
+// Never edit a migration that has shipped - append a new one instead.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "weekly digest settings and job status history",
+		query: `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS digestEnabled BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS digestDay INTEGER NOT NULL DEFAULT 1;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS digestHour INTEGER NOT NULL DEFAULT 8;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS digestToken TEXT NOT NULL DEFAULT '';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS digestLastSentAt TIMESTAMPTZ;
+
+		CREATE TABLE IF NOT EXISTS job_status_changes(
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			jobId UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+			fromStatus TEXT NOT NULL,
+			toStatus TEXT NOT NULL,
+			changedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS job_status_changes_job_idx ON job_status_changes(jobId, changedAt);
+		`,
+	},
+	{
+		version: 2,
+		name:    "interviews and calendar feed token",
+		query: `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS calendarToken TEXT NOT NULL DEFAULT '';
+
+		CREATE TABLE IF NOT EXISTS interviews(
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			jobId UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+			scheduledAt TIMESTAMPTZ NOT NULL,
+			timezone TEXT NOT NULL DEFAULT 'UTC',
+			durationMinutes INTEGER NOT NULL DEFAULT 60,
+			roundName TEXT NOT NULL DEFAULT '',
+			interviewer TEXT NOT NULL DEFAULT '',
+			location TEXT NOT NULL DEFAULT '',
+			videoLink TEXT NOT NULL DEFAULT '',
+			outcome TEXT NOT NULL DEFAULT 'pending',
+			createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS interviews_job_idx ON interviews(jobId, scheduledAt);
+		`,
+	},
+	{
+		version: 3,
+		name:    "job notes",
+		query: `
+		CREATE TABLE IF NOT EXISTS job_notes(
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			jobId UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+			body TEXT NOT NULL,
+			createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS job_notes_job_idx ON job_notes(jobId, createdAt);
+		`,
+	},
+	{
+		version: 4,
+		name:    "contacts and job contact links",
+		query: `
+		CREATE TABLE IF NOT EXISTS contacts(
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			userId UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			email TEXT NOT NULL DEFAULT '',
+			phone TEXT NOT NULL DEFAULT '',
+			linkedInUrl TEXT NOT NULL DEFAULT '',
+			company TEXT NOT NULL DEFAULT '',
+			notes TEXT NOT NULL DEFAULT '',
+			createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS contacts_user_idx ON contacts(userId, name);
+
+		CREATE TABLE IF NOT EXISTS job_contacts(
+			jobId UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+			contactId UUID NOT NULL REFERENCES contacts(id) ON DELETE CASCADE,
+			role TEXT NOT NULL,
+			createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY(jobId, contactId, role)
+		);
+		CREATE INDEX IF NOT EXISTS job_contacts_contact_idx ON job_contacts(contactId);
+		`,
+	},
+	{
+		version: 5,
+		name:    "job documents",
+		query: `
+		CREATE TABLE IF NOT EXISTS documents(
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			jobId UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+			userId UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			kind TEXT NOT NULL,
+			fileName TEXT NOT NULL,
+			contentType TEXT NOT NULL,
+			size BIGINT NOT NULL,
+			storageKey TEXT NOT NULL UNIQUE,
+			createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS documents_job_idx ON documents(jobId, createdAt);
+		CREATE INDEX IF NOT EXISTS documents_user_idx ON documents(userId);
+		`,
+	},
+	{
+		version: 6,
+		name:    "extended job fields",
+		query: `
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS workMode TEXT NOT NULL DEFAULT '';
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salaryMin BIGINT;
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salaryMax BIGINT;
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salaryCurrency TEXT NOT NULL DEFAULT '';
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salaryPeriod TEXT NOT NULL DEFAULT '';
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS postingUrl TEXT NOT NULL DEFAULT '';
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS deadline DATE;
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '';
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS priority INTEGER;
+		`,
+	},
+	{
+		version: 7,
+		name:    "tags",
+		query: `
+		CREATE TABLE IF NOT EXISTS tags(
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			userId UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS tags_user_name_idx ON tags(userId, LOWER(name));
+
+		CREATE TABLE IF NOT EXISTS job_tags(
+			jobId UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+			tagId UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+			PRIMARY KEY(jobId, tagId)
+		);
+		CREATE INDEX IF NOT EXISTS job_tags_tag_idx ON job_tags(tagId);
+		`,
+	},
+	{
+		version: 8,
+		name:    "account deletion",
+		query: `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS deletedAt TIMESTAMPTZ;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS purgeAfter TIMESTAMPTZ;
+		CREATE INDEX IF NOT EXISTS users_purge_after_idx ON users(purgeAfter) WHERE purgeAfter IS NOT NULL;
+		`,
+	},
+	{
+		version: 9,
+		name:    "job owner foreign key, constraints, indexes and updatedAt",
+		query: `
+		-- Rows this migration has to remove or rewrite are copied here first,
+		-- as they were, so nothing is lost without a record. createdBy is set
+		-- for jobs that are kept, so the record goes when their owner does
+		CREATE TABLE IF NOT EXISTS jobs_quarantine(
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			jobId UUID NOT NULL,
+			createdBy UUID REFERENCES users(id) ON DELETE CASCADE,
+			reason TEXT NOT NULL,
+			data JSONB NOT NULL,
+			quarantinedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		-- Jobs whose owner is gone (or was never a valid user ID) cannot
+		-- satisfy the foreign key, and nobody can reach them anyway. They are
+		-- kept in the quarantine together with the rows that cascade from them
+		INSERT INTO jobs_quarantine(jobId, reason, data)
+		SELECT j.id, 'owner is not a user', jsonb_build_object(
+			'job', to_jsonb(j),
+			'statusChanges', COALESCE((SELECT jsonb_agg(to_jsonb(s)) FROM job_status_changes s WHERE s.jobId = j.id), '[]'),
+			'interviews', COALESCE((SELECT jsonb_agg(to_jsonb(i)) FROM interviews i WHERE i.jobId = j.id), '[]'),
+			'notes', COALESCE((SELECT jsonb_agg(to_jsonb(n)) FROM job_notes n WHERE n.jobId = j.id), '[]'),
+			'contacts', COALESCE((SELECT jsonb_agg(to_jsonb(c)) FROM job_contacts c WHERE c.jobId = j.id), '[]'),
+			'documents', COALESCE((SELECT jsonb_agg(to_jsonb(d)) FROM documents d WHERE d.jobId = j.id), '[]'),
+			'tags', COALESCE((SELECT jsonb_agg(to_jsonb(t)) FROM job_tags t WHERE t.jobId = j.id), '[]'))
+		FROM jobs j
+		WHERE j.createdBy !~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$'
+			OR NOT EXISTS (SELECT 1 FROM users u WHERE u.id::text = LOWER(j.createdBy));
+
+		DELETE FROM jobs WHERE id IN (SELECT jobId FROM jobs_quarantine WHERE reason = 'owner is not a user');
+
+		ALTER TABLE jobs ALTER COLUMN createdBy TYPE UUID USING createdBy::uuid;
+		ALTER TABLE jobs ADD CONSTRAINT jobs_createdby_fkey
+			FOREIGN KEY (createdBy) REFERENCES users(id) ON DELETE CASCADE;
+
+		INSERT INTO jobs_quarantine(jobId, createdBy, reason, data)
+		SELECT j.id, j.createdBy, 'status was not valid, set to pending', jsonb_build_object('job', to_jsonb(j))
+		FROM jobs j WHERE j.status NOT IN ('interview', 'Accepted', 'declined', 'pending');
+		UPDATE jobs SET status = 'pending' WHERE status NOT IN ('interview', 'Accepted', 'declined', 'pending');
+		ALTER TABLE jobs ADD CONSTRAINT jobs_status_check
+			CHECK (status IN ('interview', 'Accepted', 'declined', 'pending'));
+		INSERT INTO jobs_quarantine(jobId, createdBy, reason, data)
+		SELECT j.id, j.createdBy, 'jobType was not valid, set to Full-Time', jsonb_build_object('job', to_jsonb(j))
+		FROM jobs j WHERE j.jobType NOT IN ('Full-Time', 'Part-Time', 'Contract', 'Internship', 'Remote');
+		UPDATE jobs SET jobType = 'Full-Time' WHERE jobType NOT IN ('Full-Time', 'Part-Time', 'Contract', 'Internship', 'Remote');
+		ALTER TABLE jobs ADD CONSTRAINT jobs_jobtype_check
+			CHECK (jobType IN ('Full-Time', 'Part-Time', 'Contract', 'Internship', 'Remote'));
+
+		CREATE INDEX IF NOT EXISTS jobs_owner_created_idx ON jobs(createdBy, createdAt DESC);
+		CREATE INDEX IF NOT EXISTS jobs_owner_status_idx ON jobs(createdBy, status);
+
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS updatedAt TIMESTAMPTZ;
+		UPDATE jobs SET updatedAt = createdAt WHERE updatedAt IS NULL;
+		ALTER TABLE jobs ALTER COLUMN updatedAt SET DEFAULT NOW();
+		ALTER TABLE jobs ALTER COLUMN updatedAt SET NOT NULL;
+
+		CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+		BEGIN
+			NEW.updatedAt = NOW();
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS jobs_set_updated_at ON jobs;
+		CREATE TRIGGER jobs_set_updated_at BEFORE UPDATE ON jobs
+			FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+		`,
+	},
+	{
+		version: 10,
+		name:    "job trash",
+		query: `
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS deletedAt TIMESTAMPTZ;
+		CREATE INDEX IF NOT EXISTS jobs_deleted_at_idx ON jobs(deletedAt) WHERE deletedAt IS NOT NULL;
+		`,
+	},
+	{
+		version: 11,
+		name:    "job archiving",
+		query: `
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS archivedAt TIMESTAMPTZ;
+		`,
+	},
+	{
+		version: 12,
+		name:    "job versions",
+		query: `
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+		-- Every write to a job moves it to a new version, whatever column it
+		-- touches, so ETags built from the version never go stale
+		CREATE OR REPLACE FUNCTION bump_job_version() RETURNS TRIGGER AS $$
+		BEGIN
+			NEW.version = OLD.version + 1;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS jobs_bump_version ON jobs;
+		CREATE TRIGGER jobs_bump_version BEFORE UPDATE ON jobs
+			FOR EACH ROW EXECUTE FUNCTION bump_job_version();
+
+		-- Tags are part of a job as far as clients are concerned, so adding,
+		-- removing or renaming one counts as a write to the job
+		CREATE OR REPLACE FUNCTION touch_tagged_job() RETURNS TRIGGER AS $$
+		BEGIN
+			IF TG_OP = 'DELETE' THEN
+				UPDATE jobs SET updatedAt = NOW() WHERE id = OLD.jobId;
+			ELSE
+				UPDATE jobs SET updatedAt = NOW() WHERE id = NEW.jobId;
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS job_tags_touch_job ON job_tags;
+		CREATE TRIGGER job_tags_touch_job AFTER INSERT OR DELETE ON job_tags
+			FOR EACH ROW EXECUTE FUNCTION touch_tagged_job();
+
+		CREATE OR REPLACE FUNCTION touch_renamed_tag_jobs() RETURNS TRIGGER AS $$
+		BEGIN
+			UPDATE jobs SET updatedAt = NOW() WHERE id IN (SELECT jobId FROM job_tags WHERE tagId = NEW.id);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS tags_touch_jobs ON tags;
+		CREATE TRIGGER tags_touch_jobs AFTER UPDATE OF name ON tags
+			FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name) EXECUTE FUNCTION touch_renamed_tag_jobs();
+		`,
+	},
+	{
+		version: 13,
+		name:    "idempotency keys",
+		query: `
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			userId UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			key TEXT NOT NULL,
+			requestHash TEXT NOT NULL,
+			status INTEGER,
+			contentType TEXT,
+			body BYTEA,
+			createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			expiresAt TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (userId, key)
+		);
+		CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys(expiresAt);
+		`,
+	},
+	{
+		version: 14,
+		name:    "companies",
+		query: `
+		CREATE TABLE IF NOT EXISTS companies (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			userId UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS companies_user_id_idx ON companies(userId);
+
+		-- Each normalised name points at exactly one of the user's companies
+		CREATE TABLE IF NOT EXISTS company_aliases (
+			companyId UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+			userId UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			normalizedName TEXT NOT NULL,
+			PRIMARY KEY (userId, normalizedName)
+		);
+		CREATE INDEX IF NOT EXISTS company_aliases_company_id_idx ON company_aliases(companyId);
+
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS companyId UUID REFERENCES companies(id) ON DELETE SET NULL;
+		CREATE INDEX IF NOT EXISTS jobs_company_id_idx ON jobs(companyId);
+		`,
+	},
+	{
+		version: 15,
+		name:    "parsed locations",
+		query: `
+		ALTER TABLE jobs
+			ADD COLUMN IF NOT EXISTS locationCity TEXT,
+			ADD COLUMN IF NOT EXISTS locationRegion TEXT,
+			ADD COLUMN IF NOT EXISTS locationCountry CHAR(2),
+			ADD COLUMN IF NOT EXISTS locationLat DOUBLE PRECISION,
+			ADD COLUMN IF NOT EXISTS locationLon DOUBLE PRECISION;
+		CREATE INDEX IF NOT EXISTS jobs_created_by_location_country_idx ON jobs(createdBy, locationCountry);
+
+		ALTER TABLE users
+			ADD COLUMN IF NOT EXISTS locationCity TEXT,
+			ADD COLUMN IF NOT EXISTS locationRegion TEXT,
+			ADD COLUMN IF NOT EXISTS locationCountry CHAR(2),
+			ADD COLUMN IF NOT EXISTS locationLat DOUBLE PRECISION,
+			ADD COLUMN IF NOT EXISTS locationLon DOUBLE PRECISION;
+		`,
+	},
+	{
+		version: 16,
+		name:    "exchange rates",
+		query: `
+		-- Units of each currency per US dollar
+		CREATE TABLE IF NOT EXISTS exchange_rates (
+			currency CHAR(3) PRIMARY KEY,
+			rate DOUBLE PRECISION NOT NULL CHECK (rate > 0),
+			updatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS preferredCurrency CHAR(3) NOT NULL DEFAULT 'USD';
+		`,
+	},
+	{
+		version: 17,
+		name:    "user preferences",
+		query: `
+		ALTER TABLE users
+			ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT 'en-US',
+			ADD COLUMN IF NOT EXISTS interviewReminders BOOLEAN NOT NULL DEFAULT TRUE,
+			ADD COLUMN IF NOT EXISTS reminderLeadHours INTEGER NOT NULL DEFAULT 24 CHECK (reminderLeadHours BETWEEN 1 AND 168),
+			ADD COLUMN IF NOT EXISTS targetRoles TEXT[] NOT NULL DEFAULT '{}',
+			ADD COLUMN IF NOT EXISTS desiredSalaryMin BIGINT CHECK (desiredSalaryMin >= 0),
+			ADD COLUMN IF NOT EXISTS desiredSalaryCurrency CHAR(3),
+			ADD COLUMN IF NOT EXISTS desiredSalaryPeriod TEXT;
+
+		-- Set once the reminder email for an interview has gone out
+		ALTER TABLE interviews ADD COLUMN IF NOT EXISTS reminderSentAt TIMESTAMPTZ;
+		`,
+	},
+	{
+		version: 18,
+		name:    "email changes",
+		query: `
+		-- At most one pending change per user; the token is stored as a SHA-256 hash
+		CREATE TABLE IF NOT EXISTS email_changes (
+			userId UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			newEmail TEXT NOT NULL,
+			tokenHash TEXT NOT NULL UNIQUE,
+			expiresAt TIMESTAMPTZ NOT NULL,
+			createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		`,
+	},
+	{
+		version: 19,
+		name:    "two-factor authentication",
+		query: `
+		-- totpSecret is set when enrolment starts and only used once totpEnabled
+		ALTER TABLE users
+			ADD COLUMN IF NOT EXISTS totpSecret TEXT,
+			ADD COLUMN IF NOT EXISTS totpEnabled BOOLEAN NOT NULL DEFAULT FALSE,
+			ADD COLUMN IF NOT EXISTS totpLastStep BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS totpFailedAttempts INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS totpLockedUntil TIMESTAMPTZ;
+
+		-- Recovery codes are stored as SHA-256 hashes and can each be used once
+		CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+			userId UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			codeHash TEXT NOT NULL,
+			usedAt TIMESTAMPTZ,
+			PRIMARY KEY (userId, codeHash)
+		);
+		`,
+	},
+	{
+		version: 20,
+		name:    "base currency exchange rate",
+		query: `
+		-- Rates are quoted against USD, so it always converts, even on servers
+		-- without a rates file. The old timestamp lets any rates file win.
+		INSERT INTO exchange_rates(currency, rate, updatedAt) VALUES('USD', 1, 'epoch')
+		ON CONFLICT (currency) DO NOTHING;
+		`,
+	},
+	{
+		version: 21,
+		name:    "interview revisions",
+		query: `
+		ALTER TABLE interviews ADD COLUMN IF NOT EXISTS updatedAt TIMESTAMPTZ;
+		UPDATE interviews SET updatedAt = createdAt WHERE updatedAt IS NULL;
+		ALTER TABLE interviews ALTER COLUMN updatedAt SET DEFAULT NOW();
+		ALTER TABLE interviews ALTER COLUMN updatedAt SET NOT NULL;
+		ALTER TABLE interviews ADD COLUMN IF NOT EXISTS revision INTEGER NOT NULL DEFAULT 0;
+
+		-- Calendar clients only pick up a changed event when its SEQUENCE goes
+		-- up, so every change to the event itself is a new revision. Marking
+		-- the reminder as sent is not.
+		CREATE OR REPLACE FUNCTION bump_interview_revision() RETURNS TRIGGER AS $$
+		BEGIN
+			NEW.updatedAt = NOW();
+			NEW.revision = OLD.revision + 1;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS interviews_bump_revision ON interviews;
+		CREATE TRIGGER interviews_bump_revision BEFORE UPDATE ON interviews
+			FOR EACH ROW
+			WHEN ((OLD.scheduledAt, OLD.timezone, OLD.durationMinutes, OLD.roundName, OLD.interviewer, OLD.location, OLD.videoLink, OLD.outcome)
+				IS DISTINCT FROM (NEW.scheduledAt, NEW.timezone, NEW.durationMinutes, NEW.roundName, NEW.interviewer, NEW.location, NEW.videoLink, NEW.outcome))
+			EXECUTE FUNCTION bump_interview_revision();
+		`,
+	},
+}
+
+func runMigrations() {
+	_, err := DB.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations(
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		appliedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)
+	`)
+	if err != nil {
+		log.Fatal("Could not create schema_migrations table:", err)
+	}
+
+	for _, m := range migrations {
+		var applied bool
+		err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)", m.version).Scan(&applied)
+		if err != nil {
+			log.Fatalf("Could not check migration %d: %v", m.version, err)
+		}
+		if applied {
+			continue
+		}
+
+		tx, err := DB.Begin()
+		if err != nil {
+			log.Fatalf("Could not start migration %d: %v", m.version, err)
+		}
+
+		if _, err = tx.Exec(m.query); err != nil {
+			tx.Rollback()
+			log.Fatalf("Could not apply migration %d (%s): %v", m.version, m.name, err)
+		}
+
+		if _, err = tx.Exec("INSERT INTO schema_migrations(version, name) VALUES($1, $2)", m.version, m.name); err != nil {
+			tx.Rollback()
+			log.Fatalf("Could not record migration %d: %v", m.version, err)
+		}
+
+		if err = tx.Commit(); err != nil {
+			log.Fatalf("Could not commit migration %d: %v", m.version, err)
+		}
+		log.Printf("Applied migration %d: %s", m.version, m.name)
+	}
+}
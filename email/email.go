@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/smtp"
 	"os"
+	"sort"
+	"strings"
 	"text/template"
 )
 
@@ -68,6 +70,12 @@ type EmailData struct {
 
 // SendEmail sends an email using the SMTP server
 func SendEmail(to string, subject string, name string, body string) error {
+	return SendEmailWithHeaders(to, subject, name, body, nil)
+}
+
+// SendEmailWithHeaders sends an email with extra headers, such as
+// List-Unsubscribe.
+func SendEmailWithHeaders(to string, subject string, name string, body string, headers map[string]string) error {
 	from := os.Getenv("SMTP_SENDER_EMAIL")
 	password := os.Getenv("SMTP_SENDER_PASS")
 	smtpHost := os.Getenv("SMTP_HOST")
@@ -90,7 +98,21 @@ func SendEmail(to string, subject string, name string, body string) error {
 		return fmt.Errorf("error executing email template: %w", err)
 	}
 
-	message := []byte(fmt.Sprintf("Subject: %s\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s", subject, emailBody.String()))
+	var extraHeaders strings.Builder
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := headers[key]
+		if strings.ContainsAny(key+value, "\r\n") {
+			return fmt.Errorf("invalid email header %q", key)
+		}
+		fmt.Fprintf(&extraHeaders, "%s: %s\r\n", key, value)
+	}
+
+	message := []byte(fmt.Sprintf("Subject: %s\r\n%sContent-Type: text/html; charset=UTF-8\r\n\r\n%s", subject, extraHeaders.String(), emailBody.String()))
 
 	auth := smtp.PlainAuth("", from, password, smtpHost)
 
@@ -0,0 +1,48 @@
+package email
+
+import (
+	"strings"
+	"time"
+)
+
+// monthFirstRegions write dates month first, as in "Jan 2".
+var monthFirstRegions = map[string]bool{"US": true, "PH": true, "CA": true}
+
+// twelveHourRegions write times on the 12-hour clock.
+var twelveHourRegions = map[string]bool{
+	"US": true, "CA": true, "AU": true, "NZ": true, "IN": true, "PH": true, "PK": true, "EG": true, "SA": true,
+}
+
+// FormatDateTime writes a date and time the way the locale does, such as
+// "Mon Jan 2, 3:04 PM" for en-US or "Mon 2 Jan, 15:04" for en-GB. Day and
+// month names stay in English.
+func FormatDateTime(t time.Time, locale string) string {
+	clock := "15:04"
+	if twelveHourRegions[region(locale)] {
+		clock = "3:04 PM"
+	}
+	return t.Format("Mon " + dayLayout(locale) + ", " + clock)
+}
+
+// FormatDay writes a day of the year the way the locale does, such as
+// "Jan 2" for en-US or "2 Jan" for en-GB.
+func FormatDay(t time.Time, locale string) string {
+	return t.Format(dayLayout(locale))
+}
+
+func dayLayout(locale string) string {
+	if monthFirstRegions[region(locale)] {
+		return "Jan 2"
+	}
+	return "2 Jan"
+}
+
+// region returns the region of a locale such as "en-GB". A bare "en" is
+// taken to mean American English.
+func region(locale string) string {
+	language, region, found := strings.Cut(locale, "-")
+	if !found && language == "en" {
+		return "US"
+	}
+	return region
+}
@@ -0,0 +1,156 @@
+package exporters
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"jobstar.com/api/models"
+	"jobstar.com/api/storage"
+)
+
+// WriteAccountArchive writes everything held for the user as a zip file:
+// their profile, jobs, notes, status history, interviews, contacts, tags and
+// documents, with the uploaded files themselves under documents/. Jobs in the
+// trash are included, with their deletedAt set. Jobs and files are streamed
+// rather than loaded into memory.
+func WriteAccountArchive(ctx context.Context, w io.Writer, userId string) error {
+	archive := zip.NewWriter(w)
+
+	profile, err := models.GetProfile(userId)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "profile.json", profile); err != nil {
+		return err
+	}
+
+	jobsFile, err := archive.Create("jobs.json")
+	if err != nil {
+		return err
+	}
+	jobs, err := New("json", jobsFile)
+	if err != nil {
+		return err
+	}
+	if err := models.StreamJobs(userId, models.JobFilter{Sort: "oldest", Archived: "all", Deleted: "all"}, jobs.Write); err != nil {
+		return err
+	}
+	if err := jobs.Close(); err != nil {
+		return err
+	}
+
+	notes, err := models.GetUserNotes(userId, true)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "notes.json", notes); err != nil {
+		return err
+	}
+
+	history, err := models.GetStatusChangesSince(userId, time.Time{}, true)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "status_history.json", history); err != nil {
+		return err
+	}
+
+	interviews, err := models.GetUserInterviews(userId, true)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "interviews.json", interviews); err != nil {
+		return err
+	}
+
+	contacts, err := models.GetUserContacts(userId)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "contacts.json", contacts); err != nil {
+		return err
+	}
+
+	tags, err := models.GetUserTags(userId)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "tags.json", tags); err != nil {
+		return err
+	}
+
+	companies, err := models.GetUserCompanies(userId)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "companies.json", companies); err != nil {
+		return err
+	}
+
+	documents, err := models.GetUserDocuments(userId)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "documents.json", documents); err != nil {
+		return err
+	}
+	for _, document := range documents {
+		if err := writeDocument(ctx, archive, document); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// writeJSONFile adds a JSON file to the archive. Nil slices are written as
+// empty arrays rather than null.
+func writeJSONFile(archive *zip.Writer, name string, v interface{}) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if string(data) == "null" {
+		data = []byte("[]")
+	}
+
+	_, err = f.Write(data)
+	return err
+}
+
+func writeDocument(ctx context.Context, archive *zip.Writer, document models.Document) error {
+	blob, err := storage.Store.Get(ctx, document.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			// Listed in documents.json already; the file itself is gone
+			return nil
+		}
+		return err
+	}
+	defer blob.Close()
+
+	// The ID keeps names unique; path.Base keeps the file inside documents/
+	name := fmt.Sprintf("documents/%s-%s", document.ID, path.Base("/"+document.FileName))
+	f, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: document.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, blob)
+	return err
+}
@@ -0,0 +1,171 @@
+// Package exporters writes a user's data out as files they can take
+// elsewhere.
+package exporters
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"jobstar.com/api/models"
+)
+
+// JobWriter writes jobs one at a time, so an export never has to hold them
+// all in memory. Close must be called to finish the file.
+type JobWriter interface {
+	Write(job models.Job) error
+	Close() error
+}
+
+type format struct {
+	contentType string
+	newWriter   func(w io.Writer) (JobWriter, error)
+}
+
+var formats = map[string]format{
+	"csv":  {"text/csv; charset=utf-8", newCSVWriter},
+	"json": {"application/json; charset=utf-8", newJSONWriter},
+	"xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", newXLSXWriter},
+}
+
+// FormatIsValid checks if the export format is one New understands
+func FormatIsValid(name string) bool {
+	_, ok := formats[name]
+	return ok
+}
+
+// ContentType returns the media type of files in the given format.
+func ContentType(name string) string {
+	return formats[name].contentType
+}
+
+// New returns a JobWriter producing the given format on w.
+func New(name string, w io.Writer) (JobWriter, error) {
+	f, ok := formats[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q", name)
+	}
+	return f.newWriter(w)
+}
+
+// columns are the spreadsheet columns, named like the job fields so an
+// export can be imported again as is.
+var columns = []string{
+	"id", "company", "position", "jobLocation", "status", "jobType", "workMode",
+	"salaryMin", "salaryMax", "salaryCurrency", "salaryPeriod", "postingUrl",
+	"description", "deadline", "source", "priority", "tags", "createdAt",
+}
+
+// cell is one spreadsheet value. Numbers are kept apart so XLSX can store
+// them as numbers rather than text.
+type cell struct {
+	text    string
+	number  bool
+	present bool
+}
+
+func textCell(value string) cell {
+	return cell{text: value, present: value != ""}
+}
+
+func jobCells(job models.Job) []cell {
+	cells := []cell{
+		textCell(job.ID),
+		textCell(job.Company),
+		textCell(job.Position),
+		textCell(job.JobLocation),
+		textCell(string(job.Status)),
+		textCell(string(job.JobType)),
+		textCell(string(job.WorkMode)),
+		int64Cell(job.SalaryMin),
+		int64Cell(job.SalaryMax),
+		textCell(job.SalaryCurrency),
+		textCell(string(job.SalaryPeriod)),
+		textCell(job.PostingURL),
+		textCell(job.Description),
+		{},
+		textCell(string(job.Source)),
+		{},
+		textCell(strings.Join(job.Tags, ",")),
+		textCell(job.CreatedAt.UTC().Format(time.RFC3339)),
+	}
+	if job.Deadline != nil {
+		cells[13] = textCell(job.Deadline.Format("2006-01-02"))
+	}
+	if job.Priority != nil {
+		cells[15] = cell{text: strconv.Itoa(*job.Priority), number: true, present: true}
+	}
+	return cells
+}
+
+func int64Cell(value *int64) cell {
+	if value == nil {
+		return cell{}
+	}
+	return cell{text: strconv.FormatInt(*value, 10), number: true, present: true}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (JobWriter, error) {
+	writer := &csvWriter{w: csv.NewWriter(w)}
+	return writer, writer.w.Write(columns)
+}
+
+func (c *csvWriter) Write(job models.Job) error {
+	cells := jobCells(job)
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		record[i] = cell.text
+		if !cell.number {
+			record[i] = neutralizeFormula(cell.text)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// neutralizeFormula stops spreadsheet apps from running text that happens to
+// look like a formula, by prefixing it with an apostrophe.
+func neutralizeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+type jsonWriter struct {
+	w       io.Writer
+	encoder *json.Encoder
+	count   int
+}
+
+func newJSONWriter(w io.Writer) (JobWriter, error) {
+	_, err := io.WriteString(w, "[")
+	return &jsonWriter{w: w, encoder: json.NewEncoder(w)}, err
+}
+
+func (j *jsonWriter) Write(job models.Job) error {
+	if j.count > 0 {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.count++
+	return j.encoder.Encode(job)
+}
+
+func (j *jsonWriter) Close() error {
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
@@ -0,0 +1,215 @@
+package exporters
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"jobstar.com/api/models"
+)
+
+func TestNeutralizeFormula(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"Acme", "Acme"},
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1 555 0100", "'+1 555 0100"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"a=1", "a=1"},
+		{" =1", " =1"},
+	}
+
+	for _, tt := range tests {
+		if got := neutralizeFormula(tt.value); got != tt.want {
+			t.Errorf("neutralizeFormula(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{
+		0:     "A",
+		1:     "B",
+		25:    "Z",
+		26:    "AA",
+		27:    "AB",
+		51:    "AZ",
+		52:    "BA",
+		701:   "ZZ",
+		702:   "AAA",
+		16383: "XFD",
+	}
+	for index, want := range tests {
+		if got := columnName(index); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", index, got, want)
+		}
+	}
+}
+
+func testJob() models.Job {
+	salary := int64(-5000)
+	priority := 2
+	return models.Job{
+		ID:          "job-1",
+		Company:     "=cmd|' /C calc'!A0",
+		Position:    "Engineer <R&D>",
+		JobLocation: "London",
+		Status:      models.Pending,
+		JobType:     models.FullTime,
+		SalaryMin:   &salary,
+		Priority:    &priority,
+		Tags:        []string{"go", "remote"},
+		CreatedAt:   time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := New("csv", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Write(testJob()); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || !reflect.DeepEqual(rows[0], columns) {
+		t.Fatalf("got rows %q", rows)
+	}
+
+	row := map[string]string{}
+	for i, column := range columns {
+		row[column] = rows[1][i]
+	}
+	// Text is neutralised, but numbers are written as they are even when negative
+	if row["company"] != "'=cmd|' /C calc'!A0" {
+		t.Errorf("company %q was not neutralised", row["company"])
+	}
+	if row["salaryMin"] != "-5000" || row["priority"] != "2" {
+		t.Errorf("numbers %q and %q were changed", row["salaryMin"], row["priority"])
+	}
+	if row["tags"] != "go,remote" || row["createdAt"] != "2024-01-15T09:00:00Z" || row["deadline"] != "" {
+		t.Errorf("got row %v", row)
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := New("json", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"job-1", "job-2"} {
+		job := testJob()
+		job.ID = id
+		if err := writer.Write(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var jobs []models.Job
+	if err := json.Unmarshal(buf.Bytes(), &jobs); err != nil {
+		t.Fatalf("not a JSON array: %v\n%s", err, buf.String())
+	}
+	// JSON is not opened by spreadsheet apps, so values are kept as they are
+	if len(jobs) != 2 || jobs[1].ID != "job-2" || jobs[0].Company != testJob().Company {
+		t.Errorf("got %+v", jobs)
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := New("xlsx", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := testJob()
+	job.Description = strings.Repeat("x", xlsxMaxCellLength+10)
+	if err := writer.Write(job); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sheet string
+	for _, f := range archive.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sheet = string(data)
+	}
+	if sheet == "" {
+		t.Fatal("the workbook has no sheet")
+	}
+
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`,
+		`<c r="R1" t="inlineStr"><is><t xml:space="preserve">createdAt</t></is></c>`,
+		// Cells are typed as text, so a formula is shown rather than run
+		`<c r="B2" t="inlineStr"><is><t xml:space="preserve">=cmd|&#39; /C calc&#39;!A0</t></is></c>`,
+		`<t xml:space="preserve">Engineer &lt;R&amp;D&gt;</t>`,
+		`<c r="H2"><v>-5000</v></c>`,
+		`<c r="P2"><v>2</v></c>`,
+		`<t xml:space="preserve">` + strings.Repeat("x", xlsxMaxCellLength) + `</t>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet is missing %.120s", want)
+		}
+	}
+	if strings.Contains(sheet, `r="I2"`) || strings.Contains(sheet, `r="N2"`) {
+		t.Error("empty cells should be left out")
+	}
+	if strings.Contains(sheet, strings.Repeat("x", xlsxMaxCellLength+1)) {
+		t.Error("a cell is longer than Excel allows")
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{"csv", "json", "xlsx"} {
+		if !FormatIsValid(name) || ContentType(name) == "" {
+			t.Errorf("format %q is not set up", name)
+		}
+	}
+	if FormatIsValid("pdf") {
+		t.Error("pdf should not be a valid format")
+	}
+	if _, err := New("pdf", io.Discard); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
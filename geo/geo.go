@@ -0,0 +1,356 @@
+// Package geo resolves free-text locations against an offline gazetteer of
+// cities and countries embedded in the binary, so no lookups leave the server.
+package geo
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EarthRadiusKm is the mean radius of the Earth used for distances.
+const EarthRadiusKm = 6371.0
+
+//go:embed cities.csv
+var citiesCSV []byte
+
+//go:embed countries.csv
+var countriesCSV []byte
+
+// Location is what a piece of location text resolved to. Country is an ISO
+// 3166-1 alpha-2 code. Coordinates are only known when a city was found.
+type Location struct {
+	City        string   `json:"city,omitempty"`
+	Region      string   `json:"region,omitempty"`
+	Country     string   `json:"country"`
+	CountryName string   `json:"countryName"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+}
+
+// HasCoordinates reports whether the location can be used for distances.
+func (l *Location) HasCoordinates() bool {
+	return l != nil && l.Latitude != nil && l.Longitude != nil
+}
+
+type city struct {
+	name       string
+	region     string
+	regionKeys []string
+	country    string
+	latitude   float64
+	longitude  float64
+	population int
+}
+
+type region struct {
+	name    string
+	country string
+}
+
+var (
+	cities       = map[string][]*city{}
+	countries    = map[string]string{} // normalised name or alias to code
+	countryNames = map[string]string{} // code to display name
+	regions      = map[string]region{} // normalised full region name
+	regionCodes  = map[string]bool{}   // normalised abbreviations such as "tx"
+)
+
+func init() {
+	if err := loadCountries(); err != nil {
+		panic(fmt.Sprintf("geo: countries.csv: %v", err))
+	}
+	if err := loadCities(); err != nil {
+		panic(fmt.Sprintf("geo: cities.csv: %v", err))
+	}
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no header")
+	}
+	return records[1:], nil
+}
+
+func loadCountries() error {
+	records, err := readCSV(countriesCSV)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if len(record) != 3 {
+			return fmt.Errorf("expected 3 fields, got %d", len(record))
+		}
+		code, name := record[0], record[1]
+		countryNames[code] = name
+		countries[normalize(name)] = code
+		for _, alias := range splitAliases(record[2]) {
+			countries[normalize(alias)] = code
+		}
+	}
+	return nil
+}
+
+func loadCities() error {
+	records, err := readCSV(citiesCSV)
+	if err != nil {
+		return err
+	}
+
+	for i, record := range records {
+		if len(record) != 8 {
+			return fmt.Errorf("line %d: expected 8 fields, got %d", i+2, len(record))
+		}
+
+		c := &city{name: record[0], region: record[2], country: record[4]}
+		if _, ok := countryNames[c.country]; !ok {
+			return fmt.Errorf("line %d: unknown country %q", i+2, c.country)
+		}
+		if c.latitude, err = strconv.ParseFloat(record[5], 64); err != nil {
+			return fmt.Errorf("line %d: %w", i+2, err)
+		}
+		if c.longitude, err = strconv.ParseFloat(record[6], 64); err != nil {
+			return fmt.Errorf("line %d: %w", i+2, err)
+		}
+		if c.population, err = strconv.Atoi(record[7]); err != nil {
+			return fmt.Errorf("line %d: %w", i+2, err)
+		}
+
+		regionKey := normalize(c.region)
+		c.regionKeys = []string{regionKey}
+		if record[3] != "" {
+			code := normalize(record[3])
+			c.regionKeys = append(c.regionKeys, code)
+			regionCodes[code] = true
+		}
+		if _, ok := regions[regionKey]; !ok {
+			regions[regionKey] = region{name: c.region, country: c.country}
+		}
+
+		names := append([]string{c.name}, splitAliases(record[1])...)
+		for _, name := range names {
+			key := normalize(name)
+			cities[key] = append(cities[key], c)
+		}
+	}
+	return nil
+}
+
+func splitAliases(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ";")
+}
+
+// CountryName returns the display name for an ISO country code, or the code
+// itself when the gazetteer does not know it.
+func CountryName(code string) string {
+	if name, ok := countryNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// Parse resolves location text such as "Austin, TX", "Berlin, Germany" or
+// "Remote (UK)". Other parts of the text are used to pick between cities of
+// the same name, falling back to the most populous. It returns nil when
+// nothing in the text is recognised.
+func Parse(text string) *Location {
+	parts := splitParts(text)
+	if len(parts) == 0 {
+		return nil
+	}
+
+	if best := bestCity(parts); best != nil {
+		latitude, longitude := best.latitude, best.longitude
+		return &Location{
+			City:        best.name,
+			Region:      best.region,
+			Country:     best.country,
+			CountryName: CountryName(best.country),
+			Latitude:    &latitude,
+			Longitude:   &longitude,
+		}
+	}
+
+	for _, part := range parts {
+		if code, ok := countries[part]; ok {
+			return &Location{Country: code, CountryName: CountryName(code)}
+		}
+	}
+	for _, part := range parts {
+		if r, ok := regions[part]; ok {
+			return &Location{Region: r.name, Country: r.country, CountryName: CountryName(r.country)}
+		}
+	}
+
+	return nil
+}
+
+// bestCity picks the city the parts most likely mean. A city named by one
+// part scores for each other part naming its region or country, and is ruled
+// out when another part names a region or country elsewhere.
+func bestCity(parts []string) *city {
+	var best *city
+	bestScore := -1
+
+	for i, part := range parts {
+	candidates:
+		for _, c := range cities[part] {
+			score := 0
+			for j, other := range parts {
+				if j == i {
+					continue
+				}
+				if containsString(c.regionKeys, other) {
+					score += 2
+					continue
+				}
+				if r, ok := regions[other]; ok && r.country != c.country {
+					continue candidates
+				}
+				if code, ok := countries[other]; ok {
+					if code != c.country {
+						continue candidates
+					}
+					score += 2
+				}
+			}
+
+			if best == nil || score > bestScore || (score == bestScore && c.population > best.population) {
+				best, bestScore = c, score
+			}
+		}
+	}
+
+	return best
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// workModeWords are dropped from location text, as in "Hybrid - London".
+var workModeWords = map[string]bool{
+	"remote": true, "hybrid": true, "onsite": true, "on site": true, "in office": true,
+	"office": true, "anywhere": true, "worldwide": true, "global": true,
+}
+
+// splitParts breaks location text into normalised parts on the separators
+// people use between city, region and country. A part like "London UK" that
+// matches nothing on its own is split into a city and what follows it.
+func splitParts(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return strings.ContainsRune(",/|;()[]·•", r)
+	})
+
+	var parts []string
+	for _, field := range fields {
+		for _, piece := range strings.Split(field, " - ") {
+			part := stripWorkMode(normalize(piece))
+			if part == "" {
+				continue
+			}
+			parts = append(parts, splitCityPrefix(part)...)
+		}
+	}
+	return parts
+}
+
+func stripWorkMode(part string) string {
+	if workModeWords[part] {
+		return ""
+	}
+	for word := range workModeWords {
+		part = strings.TrimPrefix(part, word+" ")
+		part = strings.TrimSuffix(part, " "+word)
+	}
+	return part
+}
+
+func splitCityPrefix(part string) []string {
+	if _, ok := cities[part]; ok {
+		return []string{part}
+	}
+
+	words := strings.Fields(part)
+	for i := len(words) - 1; i > 0; i-- {
+		prefix, rest := strings.Join(words[:i], " "), strings.Join(words[i:], " ")
+		if _, ok := cities[prefix]; !ok {
+			continue
+		}
+		_, isCountry := countries[rest]
+		_, isRegion := regions[rest]
+		if isCountry || isRegion || regionCodes[rest] {
+			return []string{prefix, rest}
+		}
+	}
+	return []string{part}
+}
+
+// placeLeading and placeTrailing are left off names, so "Greater London"
+// and "Seattle Metro Area" match their cities.
+var placeLeading = []string{"greater ", "metro "}
+var placeTrailing = []string{" metropolitan area", " metro area", " area", " metro"}
+
+// normalize folds a place name to lower-case ASCII words separated by single
+// spaces, so spelling variations compare equal.
+func normalize(name string) string {
+	name = foldAccents.Replace(strings.ToLower(name))
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	name = strings.Join(words, " ")
+
+	for _, prefix := range placeLeading {
+		if trimmed := strings.TrimPrefix(name, prefix); trimmed != "" {
+			name = trimmed
+		}
+	}
+	for _, suffix := range placeTrailing {
+		if trimmed := strings.TrimSuffix(name, suffix); trimmed != "" {
+			name = trimmed
+		}
+	}
+	return name
+}
+
+var foldAccents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a", "ą", "a", "ă", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e", "ę", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "ı", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ć", "c", "č", "c", "ñ", "n", "ń", "n", "ł", "l", "ř", "r", "ý", "y", "đ", "d", "ğ", "g",
+	"ś", "s", "š", "s", "ş", "s", "ș", "s", "ţ", "t", "ț", "t", "ź", "z", "ż", "z", "ž", "z",
+	"ß", "ss", "æ", "ae", "œ", "oe",
+)
+
+// DistanceKm is the great-circle distance between two points, by the
+// haversine formula.
+func DistanceKm(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	dLatitude := toRadians(latitude2 - latitude1)
+	dLongitude := toRadians(longitude2 - longitude1)
+	a := math.Pow(math.Sin(dLatitude/2), 2) +
+		math.Cos(toRadians(latitude1))*math.Cos(toRadians(latitude2))*math.Pow(math.Sin(dLongitude/2), 2)
+	// Rounding can push a just past 1 for antipodal points
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,70 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text    string
+		city    string
+		region  string
+		country string
+	}{
+		{"Austin, TX", "Austin", "Texas", "US"},
+		{"Berlin, Germany", "Berlin", "Berlin", "DE"},
+		{"London", "London", "England", "GB"},
+		{"London, Ontario", "London", "Ontario", "CA"},
+		{"London, Canada", "London", "Ontario", "CA"},
+		{"London UK", "London", "England", "GB"},
+		{"Hybrid - London", "London", "England", "GB"},
+		{"Greater London", "London", "England", "GB"},
+		{"Portland, ME", "Portland", "Maine", "US"},
+		{"Portland", "Portland", "Oregon", "US"},
+		{"NYC", "New York", "New York", "US"},
+		{"München, Germany", "Munich", "Bavaria", "DE"},
+		{"Zürich", "Zurich", "Zurich", "CH"},
+		{"Paris, Texas", "", "Texas", "US"},
+		{"Remote (UK)", "", "", "GB"},
+		{"Remote - United States", "", "", "US"},
+		{"Texas", "", "Texas", "US"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			location := Parse(tt.text)
+			if location == nil {
+				t.Fatal("got nil")
+			}
+			if location.City != tt.city || location.Region != tt.region || location.Country != tt.country {
+				t.Errorf("got %q, %q, %q, want %q, %q, %q",
+					location.City, location.Region, location.Country, tt.city, tt.region, tt.country)
+			}
+			if location.HasCoordinates() != (tt.city != "") {
+				t.Errorf("HasCoordinates() = %v, want %v", location.HasCoordinates(), tt.city != "")
+			}
+		})
+	}
+}
+
+func TestParseUnknown(t *testing.T) {
+	for _, text := range []string{"", "Remote", "Anywhere", "Atlantis", " , "} {
+		if location := Parse(text); location != nil {
+			t.Errorf("Parse(%q) = %+v, want nil", text, location)
+		}
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	london := Parse("London, UK")
+	paris := Parse("Paris, France")
+
+	distance := DistanceKm(*london.Latitude, *london.Longitude, *paris.Latitude, *paris.Longitude)
+	if math.Abs(distance-344) > 2 {
+		t.Errorf("London to Paris is %.1f km, want about 344", distance)
+	}
+	if d := DistanceKm(*london.Latitude, *london.Longitude, *london.Latitude, *london.Longitude); d != 0 {
+		t.Errorf("distance to itself is %v, want 0", d)
+	}
+}
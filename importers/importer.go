@@ -0,0 +1,62 @@
+package importers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Importer parses one export format into records keyed by job field name.
+type Importer interface {
+	Parse(r io.Reader, maxRows int) ([]Record, error)
+}
+
+type csvImporter struct{ mapping Mapping }
+
+func (i csvImporter) Parse(r io.Reader, maxRows int) ([]Record, error) {
+	return ParseCSV(r, i.mapping, maxRows)
+}
+
+type jsonImporter struct{ mapping Mapping }
+
+func (i jsonImporter) Parse(r io.Reader, maxRows int) ([]Record, error) {
+	return ParseJSON(r, i.mapping, maxRows)
+}
+
+// trackers holds the importers for other job trackers' exports, keyed by
+// format name.
+var trackers = map[string]Importer{
+	"huntr":    huntr,
+	"teal":     teal,
+	"linkedin": linkedIn,
+}
+
+// Formats lists every format New accepts.
+func Formats() []string {
+	formats := []string{"csv", "json"}
+	for name := range trackers {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats[2:])
+	return formats
+}
+
+// New returns the importer for a format. A column mapping only applies to
+// the generic csv and json formats, as tracker exports have fixed columns.
+func New(format string, mapping Mapping) (Importer, error) {
+	switch format {
+	case "csv":
+		return csvImporter{mapping}, nil
+	case "json":
+		return jsonImporter{mapping}, nil
+	}
+
+	importer, ok := trackers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+	if len(mapping) > 0 {
+		return nil, fmt.Errorf("a column mapping cannot be used with the %s format", format)
+	}
+	return importer, nil
+}
@@ -0,0 +1,390 @@
+// Package importers turns spreadsheet exports into jobs ready to be
+// validated and saved.
+package importers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"jobstar.com/api/models"
+)
+
+// Record is one row of an import, keyed by job field name. Line is the
+// 1-based row number in the source, not counting the CSV header.
+type Record struct {
+	Line   int
+	Fields map[string]string
+}
+
+// Fields lists the job fields a column can be mapped to.
+var Fields = []string{
+	"company", "position", "jobLocation", "status", "jobType", "workMode",
+	"salaryMin", "salaryMax", "salaryCurrency", "salaryPeriod", "postingUrl",
+	"description", "deadline", "source", "priority", "tags", "createdAt",
+}
+
+// fieldKeys indexes Fields by their normalised form so headers such as
+// "Job Location" or "job_location" find jobLocation.
+var fieldKeys = func() map[string]string {
+	keys := make(map[string]string, len(Fields))
+	for _, field := range Fields {
+		keys[normalizeHeader(field)] = field
+	}
+	return keys
+}()
+
+func normalizeHeader(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if r == ' ' || r == '_' || r == '-' || r == '.' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Mapping maps source column headers to job field names. Headers that are
+// not in the mapping are matched to a field by name, ignoring case, spaces,
+// underscores and dashes; anything else is ignored.
+type Mapping map[string]string
+
+// Validate checks that every mapping target is a known job field.
+func (m Mapping) Validate() error {
+	for header, field := range m {
+		if _, ok := fieldKeys[normalizeHeader(field)]; !ok {
+			return fmt.Errorf("column %q is mapped to unknown field %q", header, field)
+		}
+	}
+	return nil
+}
+
+func (m Mapping) field(header string) (string, bool) {
+	if field, ok := m[header]; ok {
+		field, ok = fieldKeys[normalizeHeader(field)]
+		return field, ok
+	}
+	field, ok := fieldKeys[normalizeHeader(header)]
+	return field, ok
+}
+
+// ParseCSV reads a CSV file with a header row. At most maxRows data rows are
+// read.
+func ParseCSV(r io.Reader, mapping Mapping, maxRows int) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("file is empty")
+		}
+		return nil, err
+	}
+	// Spreadsheet apps like to start UTF-8 files with a byte order mark
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	var records []Record
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line > maxRows {
+			return nil, fmt.Errorf("file has more than %d rows", maxRows)
+		}
+
+		record := Record{Line: line, Fields: map[string]string{}}
+		for i, value := range row {
+			if i >= len(header) {
+				break
+			}
+			if field, ok := mapping.field(strings.TrimSpace(header[i])); ok {
+				record.Fields[field] = strings.TrimSpace(value)
+			}
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// ParseJSON reads a JSON array of objects. Non-string values are converted
+// to text, and arrays (such as a list of tags) are joined with commas.
+func ParseJSON(r io.Reader, mapping Mapping, maxRows int) ([]Record, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var rows []map[string]interface{}
+	if err := decoder.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of objects: %w", err)
+	}
+	if len(rows) > maxRows {
+		return nil, fmt.Errorf("file has more than %d rows", maxRows)
+	}
+
+	records := make([]Record, 0, len(rows))
+	for i, row := range rows {
+		record := Record{Line: i + 1, Fields: map[string]string{}}
+
+		// Walk keys in order so duplicate mappings resolve the same way every time
+		keys := make([]string, 0, len(row))
+		for key := range row {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if field, ok := mapping.field(key); ok {
+				record.Fields[field] = jsonText(row[key])
+			}
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func jsonText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, jsonText(item))
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ToJob converts a record into a job owned by userId. Values that cannot be
+// parsed are reported in the returned errors; the job is then not usable.
+// Enum values are matched ignoring case, so "Accepted", "accepted" and
+// "ACCEPTED" all work.
+func (r Record) ToJob(userId string) (models.Job, []string) {
+	var problems []string
+	f := r.Fields
+
+	job := models.Job{
+		Company:        f["company"],
+		Position:       f["position"],
+		JobLocation:    f["jobLocation"],
+		Status:         models.Status(matchValue(f["status"], models.Interview, models.Accepted, models.Declined, models.Pending)),
+		JobType:        models.JobType(matchValue(f["jobType"], models.FullTime, models.PartTime, models.Contract, models.Internship, models.Remote)),
+		WorkMode:       models.WorkMode(strings.ToLower(f["workMode"])),
+		SalaryCurrency: f["salaryCurrency"],
+		SalaryPeriod:   models.SalaryPeriod(strings.ToLower(f["salaryPeriod"])),
+		PostingURL:     f["postingUrl"],
+		Description:    f["description"],
+		Source:         models.JobSource(strings.ToLower(f["source"])),
+		CreatedBy:      userId,
+	}
+
+	for _, field := range []string{"salaryMin", "salaryMax"} {
+		if f[field] == "" {
+			continue
+		}
+		amount, err := strconv.ParseInt(strings.ReplaceAll(f[field], ",", ""), 10, 64)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s must be a whole number", field))
+			continue
+		}
+		if field == "salaryMin" {
+			job.SalaryMin = &amount
+		} else {
+			job.SalaryMax = &amount
+		}
+	}
+
+	if f["priority"] != "" {
+		priority, err := strconv.Atoi(f["priority"])
+		if err != nil {
+			problems = append(problems, "priority must be a whole number")
+		} else {
+			job.Priority = &priority
+		}
+	}
+
+	if f["deadline"] != "" {
+		deadline, err := parseDate(f["deadline"])
+		if err != nil {
+			problems = append(problems, "deadline must be a date (YYYY-MM-DD)")
+		} else {
+			job.Deadline = &models.Date{Time: deadline}
+		}
+	}
+
+	if f["createdAt"] != "" {
+		createdAt, err := parseDate(f["createdAt"])
+		if err != nil {
+			problems = append(problems, "createdAt must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+		} else if createdAt.After(time.Now()) {
+			problems = append(problems, "createdAt cannot be in the future")
+		} else {
+			job.CreatedAt = createdAt
+		}
+	}
+
+	// Only tracker importers set archived, for stages that mean the job was
+	// put away; it is archived as of the import.
+	if f["archived"] == "true" {
+		archivedAt := time.Now()
+		job.ArchivedAt = &archivedAt
+	}
+
+	if f["tags"] != "" {
+		for _, tag := range strings.Split(f["tags"], ",") {
+			if strings.TrimSpace(tag) == "" {
+				continue
+			}
+			name, err := models.NormalizeTagName(tag)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			job.Tags = append(job.Tags, name)
+		}
+	}
+
+	return job, problems
+}
+
+// matchValue returns the option equal to value ignoring case, or value
+// itself so validation can report it.
+func matchValue[T ~string](value string, options ...T) string {
+	for _, option := range options {
+		if strings.EqualFold(value, string(option)) {
+			return string(option)
+		}
+	}
+	return value
+}
+
+// dateLayouts are tried in order. Exports from US-based trackers write
+// month before day, so ambiguous dates such as 3/4/2024 are read that way.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"1/2/06, 3:04 PM",
+	"1/2/2006, 3:04 PM",
+	"1/2/2006 15:04",
+	"1/2/2006",
+	"1/2/06",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"2 Jan 2006",
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
+
+// RowResult reports what happened to one row of an import.
+type RowResult struct {
+	Row        int                     `json:"row"`
+	Status     string                  `json:"status"` // valid, invalid, duplicate or imported
+	Errors     []string                `json:"errors,omitempty"`
+	JobID      string                  `json:"jobId,omitempty"`
+	Duplicates []models.DuplicateMatch `json:"duplicates,omitempty"`
+}
+
+// Report summarises an import, row by row.
+type Report struct {
+	DryRun    bool        `json:"dryRun"`
+	Total     int         `json:"total"`
+	Valid     int         `json:"valid"`
+	Invalid   int         `json:"invalid"`
+	Duplicate int         `json:"duplicate"`
+	Imported  int         `json:"imported"`
+	Rows      []RowResult `json:"rows"`
+}
+
+// Prepare converts and validates every record, returning the jobs that can
+// be saved alongside a report covering all rows. Rows whose company,
+// position and date match an existing job key, or an earlier row, are
+// skipped as duplicates, as are rows the finder thinks look like an existing
+// job unless finder is nil.
+func Prepare(records []Record, userId string, existing map[string]bool, finder *models.DuplicateFinder) ([]models.Job, *Report) {
+	report := &Report{Total: len(records), Rows: make([]RowResult, 0, len(records))}
+	var jobs []models.Job
+
+	for _, record := range records {
+		job, problems := record.ToJob(userId)
+		if err := job.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+
+		if len(problems) > 0 {
+			report.Invalid++
+			report.Rows = append(report.Rows, RowResult{Row: record.Line, Status: "invalid", Errors: problems})
+			continue
+		}
+
+		if job.CreatedAt.IsZero() {
+			job.CreatedAt = time.Now()
+		}
+		key := models.JobKey(job.Company, job.Position, job.CreatedAt)
+		if existing[key] {
+			report.Duplicate++
+			report.Rows = append(report.Rows, RowResult{Row: record.Line, Status: "duplicate",
+				Errors: []string{"a job with this company, position and date already exists"}})
+			continue
+		}
+
+		if finder != nil {
+			if duplicates := finder.Find(job.Company, job.Position, job.CreatedAt); len(duplicates) > 0 {
+				report.Duplicate++
+				report.Rows = append(report.Rows, RowResult{Row: record.Line, Status: "duplicate",
+					Errors: []string{"this looks like a job you have already added"}, Duplicates: duplicates})
+				continue
+			}
+		}
+		existing[key] = true
+
+		report.Valid++
+		report.Rows = append(report.Rows, RowResult{Row: record.Line, Status: "valid"})
+		jobs = append(jobs, job)
+	}
+
+	return jobs, report
+}
+
+// MarkImported records the IDs of the saved jobs, which must be in the
+// order Prepare returned them.
+func (r *Report) MarkImported(jobs []models.Job) {
+	next := 0
+	for i := range r.Rows {
+		if r.Rows[i].Status != "valid" || next >= len(jobs) {
+			continue
+		}
+		r.Rows[i].Status = "imported"
+		r.Rows[i].JobID = jobs[next].ID
+		next++
+	}
+	r.Imported = next
+}
@@ -0,0 +1,215 @@
+package importers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"jobstar.com/api/models"
+)
+
+func TestMappingField(t *testing.T) {
+	mapping := Mapping{"Employer": "company", "Where": "job_location"}
+
+	tests := []struct {
+		header string
+		field  string
+		ok     bool
+	}{
+		{"company", "company", true},
+		{"Company", "company", true},
+		{"Job Location", "jobLocation", true},
+		{"job_location", "jobLocation", true},
+		{"salary-min", "salaryMin", true},
+		{"Posting.URL", "postingUrl", true},
+		{"Employer", "company", true},
+		{"Where", "jobLocation", true},
+		{"Notes", "", false},
+	}
+
+	for _, tt := range tests {
+		field, ok := mapping.field(tt.header)
+		if field != tt.field || ok != tt.ok {
+			t.Errorf("field(%q) = %q, %v, want %q, %v", tt.header, field, ok, tt.field, tt.ok)
+		}
+	}
+}
+
+func TestMappingValidate(t *testing.T) {
+	if err := (Mapping{"Employer": "Company", "Title": "position"}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (Mapping{"Employer": "employer"}).Validate(); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	input := "\uFEFFCompany, Job Title ,Job Location,Notes\n" +
+		"Acme, Backend Engineer , London,ignored\n" +
+		"Globex,Designer\n" +
+		"Initech,QA,Austin,x,extra\n"
+
+	records, err := ParseCSV(strings.NewReader(input), Mapping{"Job Title": "position"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Record{
+		{Line: 1, Fields: map[string]string{"company": "Acme", "position": "Backend Engineer", "jobLocation": "London"}},
+		{Line: 2, Fields: map[string]string{"company": "Globex", "position": "Designer"}},
+		{Line: 3, Fields: map[string]string{"company": "Initech", "position": "QA", "jobLocation": "Austin"}},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %+v, want %+v", records, want)
+	}
+}
+
+func TestParseCSVLimits(t *testing.T) {
+	if _, err := ParseCSV(strings.NewReader(""), nil, 10); err == nil {
+		t.Error("expected an error for an empty file")
+	}
+
+	input := "company,position\na,b\nc,d\ne,f\n"
+	if _, err := ParseCSV(strings.NewReader(input), nil, 3); err != nil {
+		t.Errorf("3 rows with a limit of 3: %v", err)
+	}
+	if _, err := ParseCSV(strings.NewReader(input), nil, 2); err == nil {
+		t.Error("expected an error for more rows than the limit")
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	input := `[
+		{"Company": " Acme ", "position": "Engineer", "salary_min": 90000, "tags": ["go", "remote"], "ignored": true},
+		{"company": "Globex", "position": null}
+	]`
+
+	records, err := ParseJSON(strings.NewReader(input), nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Record{
+		{Line: 1, Fields: map[string]string{"company": "Acme", "position": "Engineer", "salaryMin": "90000", "tags": "go,remote"}},
+		{Line: 2, Fields: map[string]string{"company": "Globex", "position": ""}},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %+v, want %+v", records, want)
+	}
+
+	if _, err := ParseJSON(strings.NewReader(`{"company": "Acme"}`), nil, 10); err == nil {
+		t.Error("expected an error for an object instead of an array")
+	}
+	if _, err := ParseJSON(strings.NewReader(`[{}, {}, {}]`), nil, 2); err == nil {
+		t.Error("expected an error for more rows than the limit")
+	}
+}
+
+func TestRecordToJob(t *testing.T) {
+	record := Record{Line: 1, Fields: map[string]string{
+		"company": "Acme", "position": "Engineer", "jobLocation": "London",
+		"status": "INTERVIEW", "jobType": "full-time", "workMode": "Remote",
+		"salaryMin": "90,000", "salaryMax": "110000", "salaryCurrency": "gbp", "salaryPeriod": "Year",
+		"deadline": "3/4/2024", "priority": "4", "tags": "go, ,backend", "createdAt": "2024-01-15",
+	}}
+
+	job, problems := record.ToJob("user-1")
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if job.Status != models.Interview || job.JobType != models.FullTime || job.WorkMode != "remote" {
+		t.Errorf("enums not matched: %q, %q, %q", job.Status, job.JobType, job.WorkMode)
+	}
+	if *job.SalaryMin != 90000 || *job.SalaryMax != 110000 {
+		t.Errorf("salary %d-%d, want 90000-110000", *job.SalaryMin, *job.SalaryMax)
+	}
+	if got := job.Deadline.Format("2006-01-02"); got != "2024-03-04" {
+		t.Errorf("deadline %s, want 2024-03-04 (month first)", got)
+	}
+	if !reflect.DeepEqual(job.Tags, []string{"go", "backend"}) {
+		t.Errorf("tags %v, want [go backend]", job.Tags)
+	}
+	if job.CreatedBy != "user-1" || job.CreatedAt.Format("2006-01-02") != "2024-01-15" {
+		t.Errorf("createdBy %q createdAt %v", job.CreatedBy, job.CreatedAt)
+	}
+
+	bad := Record{Line: 2, Fields: map[string]string{
+		"salaryMin": "lots", "priority": "high", "deadline": "soon",
+		"createdAt": time.Now().AddDate(1, 0, 0).Format("2006-01-02"),
+	}}
+	_, problems = bad.ToJob("user-1")
+	if len(problems) != 4 {
+		t.Errorf("got problems %v, want one each for salaryMin, priority, deadline and createdAt", problems)
+	}
+}
+
+func TestPrepareReport(t *testing.T) {
+	row := func(line int, company, position, createdAt string) Record {
+		return Record{Line: line, Fields: map[string]string{
+			"company": company, "position": position, "jobLocation": "London",
+			"jobType": "Full-Time", "createdAt": createdAt,
+		}}
+	}
+	records := []Record{
+		row(1, "Acme", "Engineer", "2024-01-15"),
+		row(2, "", "Engineer", "2024-01-15"),
+		row(3, "ACME ", "engineer", "2024-01-15"),
+		row(4, "Globex", "Designer", "2024-01-16"),
+		row(5, "Initech", "QA", "2024-01-17"),
+	}
+	existing := map[string]bool{models.JobKey("Initech", "QA", time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC)): true}
+
+	jobs, report := Prepare(records, "user-1", existing, nil)
+
+	if len(jobs) != 2 || jobs[0].Company != "Acme" || jobs[1].Company != "Globex" {
+		t.Fatalf("got jobs %+v, want Acme and Globex", jobs)
+	}
+	if report.Total != 5 || report.Valid != 2 || report.Invalid != 1 || report.Duplicate != 2 || report.Imported != 0 {
+		t.Errorf("got counts %+v", report)
+	}
+
+	statuses := make([]string, len(report.Rows))
+	for i, result := range report.Rows {
+		statuses[i] = result.Status
+		if result.Row != i+1 {
+			t.Errorf("row %d reported as %d", i+1, result.Row)
+		}
+	}
+	if want := []string{"valid", "invalid", "duplicate", "valid", "duplicate"}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("got statuses %v, want %v", statuses, want)
+	}
+	if len(report.Rows[1].Errors) == 0 {
+		t.Error("the invalid row has no errors")
+	}
+
+	// A dry run stops here; a real import saves the jobs and marks them
+	jobs[0].ID, jobs[1].ID = "job-1", "job-2"
+	report.MarkImported(jobs)
+	if report.Imported != 2 || report.Rows[0].Status != "imported" || report.Rows[0].JobID != "job-1" ||
+		report.Rows[3].Status != "imported" || report.Rows[3].JobID != "job-2" {
+		t.Errorf("after MarkImported: %+v", report)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if want := []string{"csv", "json", "huntr", "linkedin", "teal"}; !reflect.DeepEqual(Formats(), want) {
+		t.Errorf("Formats() = %v, want %v", Formats(), want)
+	}
+
+	for _, format := range Formats() {
+		if _, err := New(format, nil); err != nil {
+			t.Errorf("New(%q): %v", format, err)
+		}
+	}
+	if _, err := New("csv", Mapping{"Employer": "company"}); err != nil {
+		t.Errorf("csv with a mapping: %v", err)
+	}
+	if _, err := New("huntr", Mapping{"Employer": "company"}); err == nil {
+		t.Error("expected an error for a mapping with a tracker format")
+	}
+	if _, err := New("xml", nil); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
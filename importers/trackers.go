@@ -0,0 +1,225 @@
+package importers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"jobstar.com/api/models"
+)
+
+// trackerImporter reads the CSV export of another job tracker. Each job
+// field lists the column headers it may appear under, since trackers rename
+// columns between versions, and stage names are translated to a Status.
+// Stages in archived also mark the job archived.
+type trackerImporter struct {
+	name     string
+	columns  map[string][]string
+	stages   map[string]models.Status
+	archived map[string]bool
+	defaults map[string]string
+}
+
+// huntr reads the board export from Huntr, where the stage is the list the
+// job card sits in. Stages map to statuses as follows:
+//
+//	Wishlist, Applied                        pending
+//	Interview, Interviewing, Offer, Offered  interview
+//	Accepted                                 Accepted
+//	Rejected, Declined, Withdrawn            declined
+//
+// An offer is still pending the applicant's answer, so it stays with the
+// interviews rather than counting as accepted.
+var huntr = trackerImporter{
+	name: "Huntr",
+	columns: map[string][]string{
+		"company":     {"Company", "Company Name", "Employer"},
+		"position":    {"Job Title", "Title", "Position"},
+		"jobLocation": {"Location", "Job Location"},
+		"status":      {"List", "Stage", "Status"},
+		"postingUrl":  {"URL", "Job URL", "Job Post URL"},
+		"description": {"Description", "Job Description"},
+		"createdAt":   {"Date Applied", "Applied Date", "Created At", "Created"},
+		"deadline":    {"Deadline"},
+	},
+	stages: map[string]models.Status{
+		"wishlist":     models.Pending,
+		"applied":      models.Pending,
+		"interview":    models.Interview,
+		"interviewing": models.Interview,
+		"offer":        models.Interview,
+		"offered":      models.Interview,
+		"accepted":     models.Accepted,
+		"rejected":     models.Declined,
+		"declined":     models.Declined,
+		"withdrawn":    models.Declined,
+	},
+}
+
+// teal reads the job tracker export from Teal. Stages map to statuses as
+// follows:
+//
+//	Bookmarked, Saved, Applying, Applied, No Response  pending
+//	Interviewing, Interview, Negotiating, Offer        interview
+//	Accepted                                           Accepted
+//	I Withdrew, Withdrawn, Not Selected, Rejected      declined
+//	Archived                                           pending, archived
+//
+// Archiving in Teal hides a job without saying how it ended, so it comes in
+// as an archived pending job rather than a declined one.
+var teal = trackerImporter{
+	name: "Teal",
+	columns: map[string][]string{
+		"company":     {"Company", "Company Name"},
+		"position":    {"Job Position", "Job Title", "Title", "Position"},
+		"jobLocation": {"Location", "Job Location"},
+		"status":      {"Status", "Stage"},
+		"postingUrl":  {"URL", "Job URL", "Job Posting URL"},
+		"description": {"Job Description", "Description"},
+		"createdAt":   {"Date Applied", "Applied Date", "Date Saved", "Created At"},
+		"deadline":    {"Deadline", "Follow Up Date"},
+	},
+	stages: map[string]models.Status{
+		"bookmarked":   models.Pending,
+		"saved":        models.Pending,
+		"applying":     models.Pending,
+		"applied":      models.Pending,
+		"noresponse":   models.Pending,
+		"interviewing": models.Interview,
+		"interview":    models.Interview,
+		"negotiating":  models.Interview,
+		"offer":        models.Interview,
+		"accepted":     models.Accepted,
+		"iwithdrew":    models.Declined,
+		"withdrawn":    models.Declined,
+		"notselected":  models.Declined,
+		"rejected":     models.Declined,
+		"archived":     models.Pending,
+	},
+	archived: map[string]bool{
+		"archived": true,
+	},
+}
+
+// linkedIn reads "Job Applications.csv" from LinkedIn's data export. It has
+// no stage or location, so every job comes in as a pending application
+// sourced from LinkedIn.
+var linkedIn = trackerImporter{
+	name: "LinkedIn",
+	columns: map[string][]string{
+		"company":    {"Company Name", "Company"},
+		"position":   {"Job Title", "Title"},
+		"postingUrl": {"Job Url", "Job URL"},
+		"createdAt":  {"Application Date", "Applied On"},
+	},
+	defaults: map[string]string{
+		"source": string(models.SourceLinkedIn),
+		"status": string(models.Pending),
+	},
+}
+
+// trackerDefaults fill in the fields JobStar requires but tracker exports
+// usually leave out.
+var trackerDefaults = map[string]string{
+	"jobType":     string(models.FullTime),
+	"jobLocation": "Not specified",
+}
+
+// stageKey reduces a stage name to lowercase letters, so "I Withdrew" and
+// "No Response 🔕" match their table entries.
+func stageKey(stage string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(stage) {
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (t trackerImporter) Parse(r io.Reader, maxRows int) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("file is empty")
+		}
+		return nil, err
+	}
+
+	positions := map[string]int{}
+	for i, name := range header {
+		key := normalizeHeader(strings.TrimPrefix(name, "\ufeff"))
+		if _, seen := positions[key]; !seen {
+			positions[key] = i
+		}
+	}
+
+	columns := map[string]int{}
+	for field, candidates := range t.columns {
+		for _, candidate := range candidates {
+			if i, ok := positions[normalizeHeader(candidate)]; ok {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	if _, ok := columns["company"]; !ok {
+		return nil, fmt.Errorf("this does not look like a %s export: no company column", t.name)
+	}
+	if _, ok := columns["position"]; !ok {
+		return nil, fmt.Errorf("this does not look like a %s export: no job title column", t.name)
+	}
+
+	var records []Record
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line > maxRows {
+			return nil, fmt.Errorf("file has more than %d rows", maxRows)
+		}
+
+		record := Record{Line: line, Fields: map[string]string{}}
+		for field, i := range columns {
+			if i < len(row) {
+				record.Fields[field] = strings.TrimSpace(row[i])
+			}
+		}
+
+		if stage := record.Fields["status"]; stage != "" {
+			key := stageKey(stage)
+			if status, ok := t.stages[key]; ok {
+				record.Fields["status"] = string(status)
+			}
+			if t.archived[key] {
+				record.Fields["archived"] = "true"
+			}
+		}
+
+		for field, value := range t.defaults {
+			if record.Fields[field] == "" {
+				record.Fields[field] = value
+			}
+		}
+		for field, value := range trackerDefaults {
+			if record.Fields[field] == "" {
+				record.Fields[field] = value
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
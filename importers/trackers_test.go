@@ -0,0 +1,181 @@
+package importers
+
+import (
+	"strings"
+	"testing"
+
+	"jobstar.com/api/models"
+)
+
+func TestStageKey(t *testing.T) {
+	tests := map[string]string{
+		"Applied":       "applied",
+		"I Withdrew":    "iwithdrew",
+		"No Response 🔕": "noresponse",
+		"not-selected":  "notselected",
+		"":              "",
+	}
+	for stage, want := range tests {
+		if got := stageKey(stage); got != want {
+			t.Errorf("stageKey(%q) = %q, want %q", stage, got, want)
+		}
+	}
+}
+
+func TestTrackerStages(t *testing.T) {
+	tests := []struct {
+		tracker  trackerImporter
+		stage    string
+		status   models.Status
+		archived bool
+	}{
+		{huntr, "Wishlist", models.Pending, false},
+		{huntr, "Applied", models.Pending, false},
+		{huntr, "Interviewing", models.Interview, false},
+		{huntr, "Offer", models.Interview, false},
+		{huntr, "Offered", models.Interview, false},
+		{huntr, "Accepted", models.Accepted, false},
+		{huntr, "Withdrawn", models.Declined, false},
+		{teal, "Bookmarked", models.Pending, false},
+		{teal, "No Response 🔕", models.Pending, false},
+		{teal, "Interviewing", models.Interview, false},
+		{teal, "Negotiating", models.Interview, false},
+		{teal, "Offer", models.Interview, false},
+		{teal, "Accepted", models.Accepted, false},
+		{teal, "I Withdrew", models.Declined, false},
+		{teal, "Not Selected", models.Declined, false},
+		{teal, "Archived", models.Pending, true},
+	}
+
+	for _, tt := range tests {
+		input := "Company,Job Title,Status\nAcme,Engineer," + tt.stage + "\n"
+		if tt.tracker.name == "Huntr" {
+			input = "Company,Job Title,List\nAcme,Engineer," + tt.stage + "\n"
+		}
+		records, err := tt.tracker.Parse(strings.NewReader(input), 10)
+		if err != nil {
+			t.Fatalf("%s %q: %v", tt.tracker.name, tt.stage, err)
+		}
+
+		job, problems := records[0].ToJob("user-1")
+		if len(problems) > 0 {
+			t.Fatalf("%s %q: %v", tt.tracker.name, tt.stage, problems)
+		}
+		if job.Status != tt.status {
+			t.Errorf("%s %q: status %q, want %q", tt.tracker.name, tt.stage, job.Status, tt.status)
+		}
+		if archived := job.ArchivedAt != nil; archived != tt.archived {
+			t.Errorf("%s %q: archived %v, want %v", tt.tracker.name, tt.stage, archived, tt.archived)
+		}
+	}
+}
+
+func TestTrackerUnknownStage(t *testing.T) {
+	records, err := huntr.Parse(strings.NewReader("Company,Title,Stage\nAcme,Engineer,Ghosted\n"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The stage is kept as written and rejected when the job is validated
+	job, problems := records[0].ToJob("user-1")
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	if err := job.Validate(); err == nil {
+		t.Errorf("status %q passed validation", job.Status)
+	}
+}
+
+func TestHuntrParse(t *testing.T) {
+	input := "\uFEFFCompany Name,Title,Location,List,Job URL,Date Applied,Notes\n" +
+		"Acme,Backend Engineer,London,Applied,https://acme.example/jobs/1,2024-01-15,ignored\n" +
+		"Globex,Designer,,Wishlist\n"
+
+	records, err := huntr.Parse(strings.NewReader(input), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+
+	acme, problems := records[0].ToJob("user-1")
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	if err := acme.Validate(); err != nil {
+		t.Errorf("Acme: %v", err)
+	}
+	if acme.Company != "Acme" || acme.Position != "Backend Engineer" || acme.JobLocation != "London" ||
+		acme.PostingURL != "https://acme.example/jobs/1" || acme.CreatedAt.Format("2006-01-02") != "2024-01-15" {
+		t.Errorf("Acme: %+v", acme)
+	}
+
+	// Short rows and missing locations fall back to the tracker defaults
+	globex, _ := records[1].ToJob("user-1")
+	if globex.JobLocation != "Not specified" || globex.JobType != models.FullTime || globex.Status != models.Pending {
+		t.Errorf("Globex: %+v", globex)
+	}
+	if err := globex.Validate(); err != nil {
+		t.Errorf("Globex: %v", err)
+	}
+}
+
+func TestTealParse(t *testing.T) {
+	input := "Company,Job Position,Location,Status,Job Posting URL,Date Saved,Follow Up Date\n" +
+		"Acme,Engineer,\"Austin, TX\",Interviewing,https://acme.example/1,2024-02-01,2024-03-01\n"
+
+	records, err := teal.Parse(strings.NewReader(input), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, problems := records[0].ToJob("user-1")
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	if job.Position != "Engineer" || job.JobLocation != "Austin, TX" || job.Status != models.Interview ||
+		job.Deadline == nil || job.Deadline.Format("2006-01-02") != "2024-03-01" {
+		t.Errorf("got %+v", job)
+	}
+}
+
+func TestLinkedInParse(t *testing.T) {
+	input := "Application Date,Contact Email,Contact Phone Number,Company Name,Job Title,Job Url,Resume Name,Question And Answers\n" +
+		"2024-01-15,me@example.com,,Acme,Engineer,https://www.linkedin.com/jobs/view/1,cv.pdf,\n"
+
+	records, err := linkedIn.Parse(strings.NewReader(input), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, problems := records[0].ToJob("user-1")
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	if err := job.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if job.Source != models.SourceLinkedIn || job.Status != models.Pending || job.JobLocation != "Not specified" ||
+		job.PostingURL != "https://www.linkedin.com/jobs/view/1" {
+		t.Errorf("got %+v", job)
+	}
+}
+
+func TestTrackerParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		rows  int
+	}{
+		{"empty file", "", 10},
+		{"no company column", "Employer Name,Job Title\nAcme,Engineer\n", 10},
+		{"no job title column", "Company,Role\nAcme,Engineer\n", 10},
+		{"too many rows", "Company,Job Title\na,b\nc,d\n", 1},
+	}
+
+	for _, tt := range tests {
+		if _, err := teal.Parse(strings.NewReader(tt.input), tt.rows); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
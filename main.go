@@ -10,6 +10,7 @@ package main
 import (
 	"log"
 	"os"
+	_ "time/tzdata" // Embeds the IANA timezone database so user timezones work on any host
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -17,7 +18,10 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"jobstar.com/api/db"
 	_ "jobstar.com/api/docs" // This import is required to include the generated docs
+	"jobstar.com/api/models"
 	"jobstar.com/api/routes"
+	"jobstar.com/api/storage"
+	"jobstar.com/api/workers"
 )
 
 func init() {
@@ -27,8 +31,30 @@ func init() {
 	}
 }
 
+// loadExchangeRates stores the rates from EXCHANGE_RATES_FILE, where set,
+// unless the database already has newer ones.
+func loadExchangeRates() {
+	path := os.Getenv("EXCHANGE_RATES_FILE")
+	if path == "" {
+		return
+	}
+
+	if err := models.LoadExchangeRateFile(path); err != nil {
+		log.Fatalf("Could not load exchange rates from %s: %v", path, err)
+	}
+}
+
 func main() {
 	db.InitDB()
+	storage.InitStore()
+	loadExchangeRates()
+	workers.StartDigestWorker()
+	workers.StartInterviewReminderWorker()
+	workers.StartAccountPurgeWorker()
+	workers.StartJobTrashPurgeWorker()
+	workers.StartIdempotencyCleanupWorker()
+	workers.StartCompanyBackfill()
+	workers.StartLocationBackfill()
 
 	server := gin.Default()
 
@@ -46,6 +72,36 @@ func main() {
 		routes.RegisterJobRoutes(jobRoutes)
 	}
 
+	// Contact Routes
+	contactRoutes := server.Group("/api/v1/contacts")
+	{
+		routes.RegisterContactRoutes(contactRoutes)
+	}
+
+	// Tag Routes
+	tagRoutes := server.Group("/api/v1/tags")
+	{
+		routes.RegisterTagRoutes(tagRoutes)
+	}
+
+	// Company Routes
+	companyRoutes := server.Group("/api/v1/companies")
+	{
+		routes.RegisterCompanyRoutes(companyRoutes)
+	}
+
+	// Exchange rate Routes
+	exchangeRateRoutes := server.Group("/api/v1/exchange-rates")
+	{
+		routes.RegisterExchangeRateRoutes(exchangeRateRoutes)
+	}
+
+	// Calendar feed Routes
+	calendarRoutes := server.Group("/api/v1/calendar")
+	{
+		routes.RegisterCalendarRoutes(calendarRoutes)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080" // Default value if not set
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
 	"jobstar.com/api/utils"
 )
 
@@ -24,6 +25,38 @@ func Authenticate(c *gin.Context) {
 		return
 	}
 
+	// Tokens outlive the account if it is deleted, so check it is still there
+	active, err := models.IsAccountActive(userId)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Something went wrong", err)
+		c.Abort()
+		return
+	}
+	if !active {
+		utils.RespondError(c, http.StatusUnauthorized, "Not Authorized", nil)
+		c.Abort()
+		return
+	}
+
 	c.Set("userId", userId)
 	c.Next()
 }
+
+// RequireAdmin lets only admins through. It must run after Authenticate.
+func RequireAdmin(c *gin.Context) {
+	userId := c.GetString("userId")
+
+	isAdmin, err := models.IsAdmin(userId)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Something went wrong", err)
+		c.Abort()
+		return
+	}
+	if !isAdmin {
+		utils.RespondError(c, http.StatusForbidden, "Admins only", nil)
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
@@ -0,0 +1,184 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+const (
+	defaultIdempotencyKeyTTLHours  = 24
+	defaultIdempotencyMaxBodyBytes = 16 << 20 // 16 MB, above the upload limits
+	maxIdempotencyKeyLength        = 255
+)
+
+// The idempotency key store, replaced in tests.
+var (
+	claimIdempotencyKey    = models.ClaimIdempotencyKey
+	saveIdempotentResponse = models.SaveIdempotentResponse
+	releaseIdempotencyKey  = models.ReleaseIdempotencyKey
+)
+
+// Idempotent makes a POST safe to retry when the client sends an
+// Idempotency-Key header: the first response is stored and replayed to
+// retries with the same key and body, without running the handler again.
+// It must come after Authenticate, as keys belong to a user. Requests
+// without the header are handled as usual. The body is buffered to compare
+// retries, up to IDEMPOTENCY_MAX_BODY_BYTES.
+func Idempotent(c *gin.Context) {
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		c.Next()
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		utils.RespondError(c, http.StatusBadRequest, "Idempotency-Key is too long", nil)
+		c.Abort()
+		return
+	}
+
+	userId := c.GetString("userId")
+	if userId == "" {
+		c.Next()
+		return
+	}
+
+	maxBody := utils.EnvInt("IDEMPOTENCY_MAX_BODY_BYTES", defaultIdempotencyMaxBodyBytes)
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBody+1))
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Could not read request data", err)
+		c.Abort()
+		return
+	}
+	if int64(len(body)) > maxBody {
+		utils.RespondError(c, http.StatusRequestEntityTooLarge, "Request is too large", nil)
+		c.Abort()
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	// The query string is part of the request, so a retry with ?force=true is
+	// not mistaken for the original
+	io.WriteString(hash, c.Request.Method+" "+c.Request.URL.RequestURI()+"\n")
+	hashBody(hash, c.GetHeader("Content-Type"), body)
+	requestHash := hex.EncodeToString(hash.Sum(nil))
+
+	ttl := time.Duration(utils.EnvInt("IDEMPOTENCY_KEY_TTL_HOURS", defaultIdempotencyKeyTTLHours)) * time.Hour
+	stored, err := claimIdempotencyKey(userId, key, requestHash, ttl)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrIdempotencyKeyInUse):
+			utils.RespondError(c, http.StatusConflict, "A request with this Idempotency-Key is already in progress", err)
+		case errors.Is(err, models.ErrIdempotencyKeyReused):
+			utils.RespondError(c, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request", err)
+		default:
+			utils.RespondError(c, http.StatusInternalServerError, "Something went wrong", err)
+		}
+		c.Abort()
+		return
+	}
+
+	if stored != nil {
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(stored.Status, stored.ContentType, stored.Body)
+		c.Abort()
+		return
+	}
+
+	writer := &capturingWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+
+	saved := false
+	defer func() {
+		// Server errors and panics are not worth replaying, so free the key
+		// for the client to try again
+		if !saved {
+			if err := releaseIdempotencyKey(userId, key); err != nil {
+				log.Printf("Error releasing idempotency key: %v", err)
+			}
+		}
+	}()
+
+	c.Next()
+
+	if writer.Status() >= http.StatusInternalServerError {
+		return
+	}
+
+	err = saveIdempotentResponse(userId, key, models.StoredResponse{
+		Status:      writer.Status(),
+		ContentType: writer.Header().Get("Content-Type"),
+		Body:        writer.body.Bytes(),
+	})
+	if err != nil {
+		log.Printf("Error storing idempotent response: %v", err)
+		return
+	}
+	saved = true
+}
+
+// hashBody adds the request body to the hash. Multipart bodies are hashed
+// part by part, leaving out the boundary, as clients pick a new one for each
+// retry of an upload.
+func hashBody(hash io.Writer, contentType string, body []byte) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err == nil && strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		if digest, err := multipartDigest(body, params["boundary"]); err == nil {
+			io.WriteString(hash, mediaType+"\n")
+			hash.Write(digest)
+			return
+		}
+	}
+	hash.Write(body)
+}
+
+// multipartDigest hashes each part's disposition, type and content.
+func multipartDigest(body []byte, boundary string) ([]byte, error) {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	hash := sha256.New()
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return hash.Sum(nil), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		content := sha256.New()
+		if _, err := io.Copy(content, part); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(hash, "%s\n%s\n%x\n", part.Header.Get("Content-Disposition"), part.Header.Get("Content-Type"), content.Sum(nil))
+	}
+}
+
+// capturingWriter keeps a copy of the response body as it is written.
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,267 @@
+package middlewares
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"jobstar.com/api/models"
+)
+
+// memoryKeys keeps idempotency keys in memory, with the same answers as the
+// idempotency_keys table.
+type memoryKeys struct {
+	mu   sync.Mutex
+	keys map[string]*memoryKey
+}
+
+type memoryKey struct {
+	requestHash string
+	response    *models.StoredResponse
+}
+
+func useMemoryKeys(t *testing.T) *memoryKeys {
+	t.Helper()
+	store := &memoryKeys{keys: map[string]*memoryKey{}}
+
+	claim, save, release := claimIdempotencyKey, saveIdempotentResponse, releaseIdempotencyKey
+	claimIdempotencyKey, saveIdempotentResponse, releaseIdempotencyKey = store.claim, store.save, store.release
+	t.Cleanup(func() {
+		claimIdempotencyKey, saveIdempotentResponse, releaseIdempotencyKey = claim, save, release
+	})
+	return store
+}
+
+func (s *memoryKeys) claim(userId, key, requestHash string, _ time.Duration) (*models.StoredResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.keys[userId+"\x00"+key]
+	switch {
+	case !ok:
+		s.keys[userId+"\x00"+key] = &memoryKey{requestHash: requestHash}
+		return nil, nil
+	case stored.requestHash != requestHash:
+		return nil, models.ErrIdempotencyKeyReused
+	case stored.response == nil:
+		return nil, models.ErrIdempotencyKeyInUse
+	}
+	return stored.response, nil
+}
+
+func (s *memoryKeys) save(userId, key string, response models.StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[userId+"\x00"+key].response = &response
+	return nil
+}
+
+func (s *memoryKeys) release(userId, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stored, ok := s.keys[userId+"\x00"+key]; ok && stored.response == nil {
+		delete(s.keys, userId+"\x00"+key)
+	}
+	return nil
+}
+
+// newIdempotentRouter serves POST /jobs for user-1 through Idempotent with
+// the given handler.
+func newIdempotentRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/jobs", func(c *gin.Context) { c.Set("userId", "user-1") }, Idempotent, handler)
+	return router
+}
+
+func post(router http.Handler, target, key, contentType, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if key != "" {
+		request.Header.Set("Idempotency-Key", key)
+	}
+	request.Header.Set("Content-Type", contentType)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	useMemoryKeys(t)
+	var calls atomic.Int32
+	router := newIdempotentRouter(func(c *gin.Context) {
+		n := calls.Add(1)
+		c.JSON(http.StatusCreated, gin.H{"call": n})
+	})
+
+	first := post(router, "/jobs", "key-1", "application/json", `{"company":"Acme"}`)
+	if first.Code != http.StatusCreated || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("first request: %d %v", first.Code, first.Header())
+	}
+
+	retry := post(router, "/jobs", "key-1", "application/json", `{"company":"Acme"}`)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry got %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("retry is not marked as replayed")
+	}
+	if !strings.HasPrefix(retry.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("retry content type %q", retry.Header().Get("Content-Type"))
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want once", calls.Load())
+	}
+
+	// The same key with another body or query string is a different request
+	if got := post(router, "/jobs", "key-1", "application/json", `{"company":"Globex"}`); got.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different body: got %d, want 422", got.Code)
+	}
+	if got := post(router, "/jobs?force=true", "key-1", "application/json", `{"company":"Acme"}`); got.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different query: got %d, want 422", got.Code)
+	}
+
+	// Without a key every request is handled
+	post(router, "/jobs", "", "application/json", `{"company":"Acme"}`)
+	post(router, "/jobs", "", "application/json", `{"company":"Acme"}`)
+	if calls.Load() != 3 {
+		t.Errorf("handler ran %d times, want 3", calls.Load())
+	}
+}
+
+func TestIdempotentConcurrentDuplicate(t *testing.T) {
+	useMemoryKeys(t)
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	var calls atomic.Int32
+	router := newIdempotentRouter(func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-finish
+		}
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- post(router, "/jobs", "key-1", "application/json", `{}`)
+	}()
+	<-started
+
+	// The first request is still being handled
+	if got := post(router, "/jobs", "key-1", "application/json", `{}`); got.Code != http.StatusConflict {
+		t.Errorf("duplicate in flight: got %d, want 409", got.Code)
+	}
+
+	close(finish)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Fatalf("first request: got %d", first.Code)
+	}
+
+	if got := post(router, "/jobs", "key-1", "application/json", `{}`); got.Code != http.StatusCreated || got.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry after the first finished: got %d %v", got.Code, got.Header())
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want once", calls.Load())
+	}
+}
+
+func TestIdempotentServerErrorReleasesKey(t *testing.T) {
+	store := useMemoryKeys(t)
+	var calls atomic.Int32
+	router := newIdempotentRouter(func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Something went wrong"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	if got := post(router, "/jobs", "key-1", "application/json", `{}`); got.Code != http.StatusInternalServerError {
+		t.Fatalf("first request: got %d", got.Code)
+	}
+	if len(store.keys) != 0 {
+		t.Error("the key is still held after a server error")
+	}
+	if got := post(router, "/jobs", "key-1", "application/json", `{}`); got.Code != http.StatusCreated {
+		t.Errorf("retry: got %d, want 201", got.Code)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want twice", calls.Load())
+	}
+}
+
+// multipartUpload encodes a one-file upload with the given boundary.
+func multipartUpload(t *testing.T, boundary, content string) (string, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.SetBoundary(boundary); err != nil {
+		t.Fatal(err)
+	}
+	writer.WriteField("kind", "resume")
+	part, err := writer.CreateFormFile("file", "resume.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	writer.Close()
+	return writer.FormDataContentType(), body.String()
+}
+
+func TestIdempotentMultipartRetry(t *testing.T) {
+	useMemoryKeys(t)
+	var calls atomic.Int32
+	router := newIdempotentRouter(func(c *gin.Context) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		calls.Add(1)
+		c.JSON(http.StatusCreated, gin.H{"size": file.Size})
+	})
+
+	contentType, body := multipartUpload(t, "boundary-first", "%PDF-1.7 one")
+	if got := post(router, "/jobs", "key-1", contentType, body); got.Code != http.StatusCreated {
+		t.Fatalf("upload: got %d %s", got.Code, got.Body)
+	}
+
+	// A retry is encoded again with a new boundary but carries the same file
+	contentType, body = multipartUpload(t, "boundary-retry", "%PDF-1.7 one")
+	if got := post(router, "/jobs", "key-1", contentType, body); got.Code != http.StatusCreated || got.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry: got %d %v", got.Code, got.Header())
+	}
+
+	contentType, body = multipartUpload(t, "boundary-other", "%PDF-1.7 two")
+	if got := post(router, "/jobs", "key-1", contentType, body); got.Code != http.StatusUnprocessableEntity {
+		t.Errorf("another file: got %d, want 422", got.Code)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want once", calls.Load())
+	}
+}
+
+func TestIdempotentLimits(t *testing.T) {
+	useMemoryKeys(t)
+	t.Setenv("IDEMPOTENCY_MAX_BODY_BYTES", "10")
+	router := newIdempotentRouter(func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	if got := post(router, "/jobs", "key-1", "application/json", `{"a":"12345"}`); got.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body: got %d, want 413", got.Code)
+	}
+	if got := post(router, "/jobs", "key-2", "application/json", `{"a":"1"}`); got.Code != http.StatusCreated {
+		t.Errorf("body within the limit: got %d, want 201", got.Code)
+	}
+	if got := post(router, "/jobs", strings.Repeat("k", maxIdempotencyKeyLength+1), "application/json", `{}`); got.Code != http.StatusBadRequest {
+		t.Errorf("long key: got %d, want 400", got.Code)
+	}
+}
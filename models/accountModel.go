@@ -0,0 +1,157 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"jobstar.com/api/db"
+	"jobstar.com/api/utils"
+)
+
+// ErrAccountPendingDeletion is returned when logging in to an account the
+// user has asked us to delete. It can be restored until the grace period
+// runs out.
+var ErrAccountPendingDeletion = errors.New("account is scheduled for deletion")
+
+var ErrInvalidPassword = errors.New("password is incorrect")
+
+type AccountDeletionRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type AccountRestoreRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// CheckPassword reports ErrInvalidPassword unless password is the user's
+// current password.
+func CheckPassword(userId, password string) error {
+	var hashedPassword string
+	err := db.DB.QueryRow("SELECT password FROM users WHERE id=$1", userId).Scan(&hashedPassword)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	if !utils.CheckPasswordHash(password, hashedPassword) {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+// ScheduleAccountDeletion soft-deletes the user. Their data stays in place
+// until purgeAfter, when the purge worker removes it for good.
+func ScheduleAccountDeletion(userId string, graceDays int) (time.Time, error) {
+	var purgeAfter time.Time
+	err := db.DB.QueryRow(`
+		UPDATE users SET deletedAt = NOW(), purgeAfter = NOW() + make_interval(days => $2)
+		WHERE id = $1 AND deletedAt IS NULL
+		RETURNING purgeAfter
+	`, userId, graceDays).Scan(&purgeAfter)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, errors.New("user not found")
+		}
+		return time.Time{}, err
+	}
+
+	return purgeAfter, nil
+}
+
+// RestoreAccount cancels a pending deletion, as long as the grace period has
+// not run out.
+func RestoreAccount(email, password string) error {
+	var userId, hashedPassword string
+	var deletedAt, purgeAfter sql.NullTime
+	err := db.DB.QueryRow("SELECT id, password, deletedAt, purgeAfter FROM users WHERE email=$1", email).
+		Scan(&userId, &hashedPassword, &deletedAt, &purgeAfter)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("credentials Invalid")
+		}
+		return err
+	}
+
+	if !utils.CheckPasswordHash(password, hashedPassword) {
+		return errors.New("credentials Invalid")
+	}
+	if !deletedAt.Valid {
+		return errors.New("account is not scheduled for deletion")
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE users SET deletedAt = NULL, purgeAfter = NULL
+		WHERE id = $1 AND deletedAt IS NOT NULL AND purgeAfter > NOW()
+	`, userId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("the grace period has ended and the account can no longer be restored")
+	}
+
+	return nil
+}
+
+// IsAccountActive reports whether the user exists and has not asked for
+// their account to be deleted.
+func IsAccountActive(userId string) (bool, error) {
+	var active bool
+	err := db.DB.QueryRow("SELECT deletedAt IS NULL FROM users WHERE id=$1", userId).Scan(&active)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return active, err
+}
+
+// GetAccountsDueForPurge returns the IDs of users whose grace period ended
+// before now.
+func GetAccountsDueForPurge(now time.Time) ([]string, error) {
+	rows, err := db.DB.Query("SELECT id FROM users WHERE deletedAt IS NOT NULL AND purgeAfter <= $1", now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// PurgeAccount hard-deletes the user. Everything they own goes with the
+// user row through ON DELETE CASCADE. Uploaded files must be removed from
+// storage before calling this.
+func PurgeAccount(userId string) error {
+	result, err := db.DB.Exec("DELETE FROM users WHERE id=$1 AND deletedAt IS NOT NULL AND purgeAfter <= NOW()", userId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("account is not due for purging")
+	}
+
+	return nil
+}
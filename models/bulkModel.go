@@ -0,0 +1,262 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/lib/pq"
+	"jobstar.com/api/db"
+)
+
+// MaxBulkJobs caps how many jobs a single bulk request may change.
+const MaxBulkJobs = 1000
+
+type BulkAction string
+
+const (
+	BulkSetStatus  BulkAction = "status"
+	BulkAddTags    BulkAction = "addTags"
+	BulkRemoveTags BulkAction = "removeTags"
+	BulkArchive    BulkAction = "archive"
+	BulkUnarchive  BulkAction = "unarchive"
+	BulkDelete     BulkAction = "delete"
+)
+
+// IsValid checks if the bulk action is valid
+func (a BulkAction) BulkActionIsValid() bool {
+	switch a {
+	case BulkSetStatus, BulkAddTags, BulkRemoveTags, BulkArchive, BulkUnarchive, BulkDelete:
+		return true
+	}
+	return false
+}
+
+// ErrBulkJobsNotFound is returned when some of the requested jobs do not
+// exist or belong to someone else. Nothing is changed in that case.
+var ErrBulkJobsNotFound = errors.New("some jobs were not found")
+
+// ErrTooManyBulkJobs is returned when a filter matches more than MaxBulkJobs.
+var ErrTooManyBulkJobs = errors.New("too many jobs match the filter")
+
+// BulkJobRequest targets jobs either by ID or with a filter written like the
+// job listing query string, e.g. "status=pending&tags=fintech".
+type BulkJobRequest struct {
+	Action BulkAction `json:"action" binding:"required" example:"status"`
+	IDs    []string   `json:"ids"`
+	Filter string     `json:"filter" example:"status=pending&tags=fintech"`
+	Status Status     `json:"status" example:"declined"`
+	Tags   []string   `json:"tags" example:"fintech,remote-first"`
+}
+
+// BulkJobOperation is the change a bulk request makes to every job it
+// targets. Tags must already be normalised with NormalizeTagName.
+type BulkJobOperation struct {
+	Action BulkAction
+	Status Status
+	Tags   []string
+}
+
+// BulkJobResult reports what happened to one job: updated, unchanged or
+// not_found.
+type BulkJobResult struct {
+	ID     string `json:"id"`
+	Result string `json:"result"`
+}
+
+const (
+	BulkResultUpdated   = "updated"
+	BulkResultUnchanged = "unchanged"
+	BulkResultNotFound  = "not_found"
+)
+
+// BulkUpdateJobs applies the operation to the user's jobs with the given IDs,
+// or to those matching filter when it is not nil, in a single transaction.
+// If any of the IDs is not one of the user's jobs, nothing is changed and
+// ErrBulkJobsNotFound is returned along with the per-job results.
+func BulkUpdateJobs(userId string, ids []string, filter *JobFilter, op BulkJobOperation) ([]BulkJobResult, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var targets []bulkTarget
+	if filter != nil {
+		targets, err = lockFilteredJobs(tx, userId, *filter)
+	} else {
+		normalized := make([]string, len(ids))
+		for i, id := range ids {
+			normalized[i] = strings.ToLower(strings.TrimSpace(id))
+		}
+		targets, err = lockJobsById(tx, userId, uniqueStrings(normalized))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkJobResult, len(targets))
+	missing := false
+	for i, target := range targets {
+		results[i].ID = target.id
+		if !target.found {
+			results[i].Result = BulkResultNotFound
+			missing = true
+		}
+	}
+	if missing {
+		return results, ErrBulkJobsNotFound
+	}
+
+	var tagIds []string
+	if op.Action == BulkAddTags {
+		tagIds, err = upsertTagsTx(tx, userId, op.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, target := range targets {
+		changed, err := applyBulkOperation(tx, userId, target, op, tagIds)
+		if err != nil {
+			return nil, err
+		}
+
+		results[i].Result = BulkResultUnchanged
+		if changed {
+			results[i].Result = BulkResultUpdated
+		}
+	}
+
+	return results, tx.Commit()
+}
+
+type bulkTarget struct {
+	id     string
+	status Status
+	found  bool
+}
+
+// lockJobsById locks the user's jobs with the given lowercase IDs, returning
+// them in the order asked for. IDs that are not the user's jobs come back not
+// found.
+func lockJobsById(tx *sql.Tx, userId string, ids []string) ([]bulkTarget, error) {
+	rows, err := tx.Query(`
+		SELECT id, status FROM jobs
+		WHERE createdBy = $1 AND deletedAt IS NULL AND id::text = ANY($2)
+		ORDER BY id
+		FOR UPDATE
+	`, userId, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := map[string]Status{}
+	for rows.Next() {
+		var id string
+		var status Status
+		if err := rows.Scan(&id, &status); err != nil {
+			return nil, err
+		}
+		statuses[id] = status
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	targets := make([]bulkTarget, len(ids))
+	for i, id := range ids {
+		status, found := statuses[id]
+		targets[i] = bulkTarget{id: id, status: status, found: found}
+	}
+
+	return targets, nil
+}
+
+// lockFilteredJobs locks the user's jobs matching the filter, in the order
+// the filter sorts them.
+func lockFilteredJobs(tx *sql.Tx, userId string, filter JobFilter) ([]bulkTarget, error) {
+	where, args := filter.where(userId)
+	query := "SELECT id, status FROM jobs WHERE " + where + " ORDER BY " + filter.orderBy() +
+		" LIMIT " + placeholder(len(args)+1) + " FOR UPDATE"
+
+	rows, err := tx.Query(query, append(args, MaxBulkJobs+1)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []bulkTarget
+	for rows.Next() {
+		target := bulkTarget{found: true}
+		if err := rows.Scan(&target.id, &target.status); err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(targets) > MaxBulkJobs {
+		return nil, ErrTooManyBulkJobs
+	}
+
+	return targets, nil
+}
+
+// applyBulkOperation changes one job and reports whether anything changed.
+func applyBulkOperation(tx *sql.Tx, userId string, target bulkTarget, op BulkJobOperation, tagIds []string) (bool, error) {
+	switch op.Action {
+	case BulkSetStatus:
+		if target.status == op.Status {
+			return false, nil
+		}
+		_, err := tx.Exec("UPDATE jobs SET status = $1 WHERE id = $2", op.Status, target.id)
+		if err != nil {
+			return false, err
+		}
+		return true, recordStatusChange(tx, target.id, target.status, op.Status)
+
+	case BulkAddTags:
+		linked, err := linkTagsTx(tx, target.id, tagIds)
+		return linked > 0, err
+
+	case BulkRemoveTags:
+		names := make([]string, len(op.Tags))
+		for i, tag := range op.Tags {
+			names[i] = strings.ToLower(tag)
+		}
+		return execChanged(tx, `
+			DELETE FROM job_tags
+			WHERE jobId = $1 AND tagId IN (SELECT id FROM tags WHERE userId = $2 AND LOWER(name) = ANY($3))
+		`, target.id, userId, pq.Array(names))
+
+	case BulkArchive:
+		return execChanged(tx, "UPDATE jobs SET archivedAt = NOW() WHERE id = $1 AND archivedAt IS NULL", target.id)
+
+	case BulkUnarchive:
+		return execChanged(tx, "UPDATE jobs SET archivedAt = NULL WHERE id = $1 AND archivedAt IS NOT NULL", target.id)
+
+	case BulkDelete:
+		return execChanged(tx, "UPDATE jobs SET deletedAt = NOW() WHERE id = $1 AND deletedAt IS NULL", target.id)
+	}
+
+	return false, errors.New("invalid bulk action")
+}
+
+// execChanged runs a statement and reports whether it affected any rows.
+func execChanged(tx *sql.Tx, query string, args ...interface{}) (bool, error) {
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBulkActionIsValid(t *testing.T) {
+	for _, action := range []BulkAction{BulkSetStatus, BulkAddTags, BulkRemoveTags, BulkArchive, BulkUnarchive, BulkDelete} {
+		if !action.BulkActionIsValid() {
+			t.Errorf("%q should be valid", action)
+		}
+	}
+	for _, action := range []BulkAction{"", "Status", "purge"} {
+		if action.BulkActionIsValid() {
+			t.Errorf("%q should not be valid", action)
+		}
+	}
+}
+
+// bulkJobs answers the locking SELECT with the given jobs and their
+// statuses, and every UPDATE with affected rows.
+func bulkJobs(statuses map[string]Status, affected int64) func(string, []driver.Value) stubResult {
+	return func(query string, args []driver.Value) stubResult {
+		if strings.Contains(query, "SELECT id, status FROM jobs") {
+			result := stubResult{columns: []string{"id", "status"}}
+			for id, status := range statuses {
+				result.rows = append(result.rows, []driver.Value{id, string(status)})
+			}
+			return result
+		}
+		return stubResult{affected: affected}
+	}
+}
+
+func TestBulkUpdateJobsNotFound(t *testing.T) {
+	stub := useStubDB(t, bulkJobs(map[string]Status{"a1": Pending}, 1))
+
+	results, err := BulkUpdateJobs("user-1", []string{" A1 ", "b2", "a1"}, nil, BulkJobOperation{Action: BulkArchive})
+	if !errors.Is(err, ErrBulkJobsNotFound) {
+		t.Fatalf("got %v, want ErrBulkJobsNotFound", err)
+	}
+
+	// IDs are matched ignoring case and surrounding space, once each
+	want := []BulkJobResult{{ID: "a1"}, {ID: "b2", Result: BulkResultNotFound}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("got %+v, want %+v", results, want)
+	}
+	if selects := stub.ran("SELECT id, status FROM jobs"); len(selects) != 1 || selects[0].args[1] != `{"a1","b2"}` {
+		t.Errorf("looked up %v", selects)
+	}
+	if len(stub.ran("UPDATE jobs")) > 0 || stub.commits > 0 {
+		t.Error("jobs were changed although one was not found")
+	}
+}
+
+func TestBulkUpdateJobsStatus(t *testing.T) {
+	stub := useStubDB(t, bulkJobs(map[string]Status{"a1": Pending, "b2": Declined}, 1))
+
+	results, err := BulkUpdateJobs("user-1", []string{"a1", "b2"}, nil, BulkJobOperation{Action: BulkSetStatus, Status: Declined})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []BulkJobResult{{ID: "a1", Result: BulkResultUpdated}, {ID: "b2", Result: BulkResultUnchanged}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("got %+v, want %+v", results, want)
+	}
+
+	// Only the job whose status changed is updated and gets a history entry
+	updates := stub.ran("UPDATE jobs SET status")
+	if len(updates) != 1 || updates[0].args[1] != "a1" {
+		t.Errorf("updated %v", updates)
+	}
+	changes := stub.ran("INSERT INTO job_status_changes")
+	if len(changes) != 1 || !reflect.DeepEqual(changes[0].args, []driver.Value{"a1", "pending", "declined"}) {
+		t.Errorf("recorded %v", changes)
+	}
+	if stub.commits != 1 {
+		t.Errorf("committed %d times, want once", stub.commits)
+	}
+}
+
+func TestBulkUpdateJobsUnchanged(t *testing.T) {
+	stub := useStubDB(t, bulkJobs(map[string]Status{"a1": Pending}, 0))
+
+	// The job is already archived, so the UPDATE matches no rows
+	results, err := BulkUpdateJobs("user-1", []string{"a1"}, nil, BulkJobOperation{Action: BulkArchive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Result != BulkResultUnchanged {
+		t.Errorf("got %q, want unchanged", results[0].Result)
+	}
+	if updates := stub.ran("archivedAt IS NULL"); len(updates) != 1 {
+		t.Errorf("updated %v", updates)
+	}
+}
+
+func TestBulkUpdateJobsTooMany(t *testing.T) {
+	statuses := map[string]Status{}
+	for i := 0; i <= MaxBulkJobs; i++ {
+		statuses[strings.Repeat("x", i+1)] = Pending
+	}
+	stub := useStubDB(t, bulkJobs(statuses, 1))
+
+	_, err := BulkUpdateJobs("user-1", nil, &JobFilter{Status: Pending}, BulkJobOperation{Action: BulkDelete})
+	if !errors.Is(err, ErrTooManyBulkJobs) {
+		t.Fatalf("got %v, want ErrTooManyBulkJobs", err)
+	}
+
+	// One more than the cap is fetched to tell a full page from too many
+	selects := stub.ran("SELECT id, status FROM jobs")
+	if len(selects) != 1 || selects[0].args[len(selects[0].args)-1] != int64(MaxBulkJobs+1) {
+		t.Errorf("looked up %v", selects)
+	}
+	if len(stub.ran("UPDATE jobs")) > 0 || stub.commits > 0 {
+		t.Error("jobs were changed although the filter matched too many")
+	}
+}
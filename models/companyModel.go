@@ -0,0 +1,282 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"jobstar.com/api/db"
+)
+
+// ErrCompanyNameTaken is returned when renaming a company to a name that
+// already belongs to another of the user's companies.
+var ErrCompanyNameTaken = errors.New("another company already goes by this name, add it as an alias to merge them")
+
+// Company groups a user's jobs at the same employer, however its name was
+// typed. Every name it has been seen under is kept as an alias.
+type Company struct {
+	ID        string       `json:"id"`
+	UserID    string       `json:"userId"`
+	Name      string       `json:"name"`
+	Aliases   []string     `json:"aliases"`
+	Stats     CompanyStats `json:"stats"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+// CompanyStats counts a company's applications by outcome.
+type CompanyStats struct {
+	Applications  int        `json:"applications"`
+	Pending       int        `json:"pending"`
+	Interview     int        `json:"interview"`
+	Accepted      int        `json:"accepted"`
+	Declined      int        `json:"declined"`
+	LastAppliedAt *time.Time `json:"lastAppliedAt"`
+}
+
+type CompanyRequest struct {
+	Name string `json:"name" binding:"required" example:"Google"`
+}
+
+// queryer is what *sql.DB and *sql.Tx have in common, for helpers that run
+// either inside a transaction or on their own.
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// resolveCompany returns the ID of the user's company known by this name,
+// creating the company when the name is new. Names that normalise to
+// nothing are not linked.
+func resolveCompany(q queryer, userId, name string) (*string, error) {
+	normalized := NormalizeCompanyName(name)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	var companyId string
+	err := q.QueryRow("SELECT companyId FROM company_aliases WHERE userId=$1 AND normalizedName=$2", userId, normalized).Scan(&companyId)
+	if err == nil {
+		return &companyId, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	err = q.QueryRow("INSERT INTO companies(userId, name, createdAt) VALUES($1, $2, NOW()) RETURNING id", userId, name).Scan(&companyId)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO company_aliases(companyId, userId, name, normalizedName) VALUES($1, $2, $3, $4)
+	`, companyId, userId, name, normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	return &companyId, nil
+}
+
+const companyStatsColumns = `
+	COUNT(j.id),
+	COUNT(j.id) FILTER (WHERE j.status = 'pending'),
+	COUNT(j.id) FILTER (WHERE j.status = 'interview'),
+	COUNT(j.id) FILTER (WHERE j.status = 'Accepted'),
+	COUNT(j.id) FILTER (WHERE j.status = 'declined'),
+	MAX(j.createdAt),
+	ARRAY(SELECT a.name FROM company_aliases a WHERE a.companyId = c.id ORDER BY LOWER(a.name))
+`
+
+func scanCompany(row interface{ Scan(...interface{}) error }, company *Company) error {
+	var aliases []string
+	err := row.Scan(&company.ID, &company.UserID, &company.Name, &company.CreatedAt,
+		&company.Stats.Applications, &company.Stats.Pending, &company.Stats.Interview, &company.Stats.Accepted,
+		&company.Stats.Declined, &company.Stats.LastAppliedAt, pq.Array(&aliases))
+	company.Aliases = aliases
+	return err
+}
+
+// GetUserCompanies lists the user's companies that have at least one job,
+// with their application counts, most applied to first.
+func GetUserCompanies(userId string) ([]Company, error) {
+	query := `
+		SELECT c.id, c.userId, c.name, c.createdAt, ` + companyStatsColumns + `
+		FROM companies c
+		JOIN jobs j ON j.companyId = c.id AND j.deletedAt IS NULL
+		WHERE c.userId = $1
+		GROUP BY c.id
+		ORDER BY COUNT(j.id) DESC, LOWER(c.name)
+	`
+	rows, err := db.DB.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var companies []Company
+	for rows.Next() {
+		var company Company
+		if err := scanCompany(rows, &company); err != nil {
+			return nil, err
+		}
+		companies = append(companies, company)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return companies, nil
+}
+
+func GetUserCompanyById(id, userId string) (*Company, error) {
+	query := `
+		SELECT c.id, c.userId, c.name, c.createdAt, ` + companyStatsColumns + `
+		FROM companies c
+		LEFT JOIN jobs j ON j.companyId = c.id AND j.deletedAt IS NULL
+		WHERE c.id = $1 AND c.userId = $2
+		GROUP BY c.id
+	`
+
+	var company Company
+	err := scanCompany(db.DB.QueryRow(query, id, userId), &company)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("company not found")
+		}
+		return nil, err
+	}
+
+	return &company, nil
+}
+
+// GetCompanyJobs lists the user's jobs at the company, newest first.
+func GetCompanyJobs(companyId, userId string) ([]Job, error) {
+	query := "SELECT " + jobColumns + " FROM jobs WHERE companyId = $1 AND createdBy = $2 AND deletedAt IS NULL ORDER BY createdAt DESC, id"
+	return queryJobs(query, companyId, userId)
+}
+
+// Rename changes the company's display name, keeping the new name as an
+// alias so jobs saved under it are linked here.
+func (c Company) Rename(name string) error {
+	normalized := NormalizeCompanyName(name)
+	if normalized == "" {
+		return errors.New("please provide company name")
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var owner string
+	err = tx.QueryRow("SELECT companyId FROM company_aliases WHERE userId=$1 AND normalizedName=$2", c.UserID, normalized).Scan(&owner)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec("INSERT INTO company_aliases(companyId, userId, name, normalizedName) VALUES($1, $2, $3, $4)",
+			c.ID, c.UserID, name, normalized)
+		if err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case owner != c.ID:
+		return ErrCompanyNameTaken
+	}
+
+	_, err = tx.Exec("UPDATE companies SET name=$1 WHERE id=$2 AND userId=$3", name, c.ID, c.UserID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddAlias makes the company known by another name as well and links the
+// user's jobs saved under that name. If the name already belongs to another
+// of the user's companies, that company is merged into this one.
+func (c Company) AddAlias(name string) error {
+	normalized := NormalizeCompanyName(name)
+	if normalized == "" {
+		return errors.New("please provide company name")
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var owner string
+	err = tx.QueryRow("SELECT companyId FROM company_aliases WHERE userId=$1 AND normalizedName=$2", c.UserID, normalized).Scan(&owner)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec("INSERT INTO company_aliases(companyId, userId, name, normalizedName) VALUES($1, $2, $3, $4)",
+			c.ID, c.UserID, name, normalized)
+		if err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case owner != c.ID:
+		statements := []string{
+			"UPDATE company_aliases SET companyId = $1 WHERE companyId = $2",
+			"UPDATE jobs SET companyId = $1 WHERE companyId = $2",
+			"DELETE FROM companies WHERE id = $2",
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement, c.ID, owner); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// LinkUnlinkedJobs attaches every job without a company to one, creating
+// companies as needed, and reports how many jobs it linked. It covers jobs
+// saved before companies existed; linking counts as a change to the job, so
+// their version moves on once.
+func LinkUnlinkedJobs() (int, error) {
+	rows, err := db.DB.Query("SELECT id, createdBy, company FROM jobs WHERE companyId IS NULL")
+	if err != nil {
+		return 0, err
+	}
+
+	type unlinked struct{ id, userId, company string }
+	var jobs []unlinked
+	for rows.Next() {
+		var job unlinked
+		if err := rows.Scan(&job.id, &job.userId, &job.company); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		jobs = append(jobs, job)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	linked := 0
+	for _, job := range jobs {
+		companyId, err := resolveCompany(db.DB, job.userId, job.company)
+		if err != nil {
+			return linked, err
+		}
+		if companyId == nil {
+			continue
+		}
+
+		_, err = db.DB.Exec("UPDATE jobs SET companyId = $1 WHERE id = $2 AND companyId IS NULL", *companyId, job.id)
+		if err != nil {
+			return linked, err
+		}
+		linked++
+	}
+
+	return linked, nil
+}
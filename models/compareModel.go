@@ -0,0 +1,158 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+	"jobstar.com/api/geo"
+)
+
+// MaxCompareJobs caps how many jobs one comparison can hold.
+const MaxCompareJobs = 10
+
+var ErrTooManyCompareJobs = errors.New("too many jobs to compare")
+
+// periodsPerYear turns a salary for one period into a yearly one, assuming
+// a 40-hour, 5-day week worked all 52 weeks.
+var periodsPerYear = map[SalaryPeriod]float64{
+	PerHour:  2080,
+	PerDay:   260,
+	PerWeek:  52,
+	PerMonth: 12,
+	PerYear:  1,
+}
+
+// Compensation is a salary range in one currency and pay period.
+type Compensation struct {
+	Min      *int64       `json:"min"`
+	Max      *int64       `json:"max"`
+	Currency string       `json:"currency"`
+	Period   SalaryPeriod `json:"period"`
+}
+
+// JobComparison is one column of an offer comparison: the salary as
+// entered and normalised to a yearly amount in a common currency, with what
+// else tells offers apart.
+type JobComparison struct {
+	ID             string        `json:"id"`
+	Company        string        `json:"company"`
+	Position       string        `json:"position"`
+	Status         Status        `json:"status"`
+	JobType        JobType       `json:"jobType"`
+	WorkMode       WorkMode      `json:"workMode"`
+	JobLocation    string        `json:"jobLocation"`
+	ParsedLocation *geo.Location `json:"parsedLocation"`
+	Salary         *Compensation `json:"salary"`
+	Normalized     *Compensation `json:"normalized"`
+	CreatedAt      time.Time     `json:"createdAt"`
+}
+
+// salary returns the job's salary range as entered, or nil when it has none.
+func (j Job) salary() *Compensation {
+	if j.SalaryMin == nil && j.SalaryMax == nil {
+		return nil
+	}
+	return &Compensation{Min: j.SalaryMin, Max: j.SalaryMax, Currency: j.SalaryCurrency, Period: j.SalaryPeriod}
+}
+
+// NormalizeSalary converts the job's salary range to a yearly amount in the
+// currency, rounded to whole units. It returns nil when the job has no
+// salary or its currency has no exchange rate.
+func NormalizeSalary(job Job, currency string, rates ExchangeRates) *Compensation {
+	salary := job.salary()
+	if salary == nil {
+		return nil
+	}
+	perYear, ok := periodsPerYear[salary.Period]
+	if !ok {
+		return nil
+	}
+
+	normalized := &Compensation{Currency: currency, Period: PerYear}
+	convert := func(amount *int64) (*int64, bool) {
+		if amount == nil {
+			return nil, true
+		}
+		converted, ok := rates.Convert(float64(*amount)*perYear, salary.Currency, currency)
+		if !ok {
+			return nil, false
+		}
+		rounded := int64(math.Round(converted))
+		return &rounded, true
+	}
+
+	if normalized.Min, ok = convert(salary.Min); !ok {
+		return nil
+	}
+	if normalized.Max, ok = convert(salary.Max); !ok {
+		return nil
+	}
+	return normalized
+}
+
+// GetUserJobsByIds returns the user's jobs with the given IDs, in the order
+// asked for. It also returns the IDs that were not found.
+func GetUserJobsByIds(ids []string, userId string) ([]Job, []string, error) {
+	query := "SELECT " + jobColumns + " FROM jobs WHERE id::text = ANY($1) AND createdBy = $2 AND deletedAt IS NULL"
+	found, err := queryJobs(query, pq.Array(ids), userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byId := make(map[string]Job, len(found))
+	for _, job := range found {
+		byId[job.ID] = job
+	}
+
+	var jobs []Job
+	var missing []string
+	for _, id := range ids {
+		job, ok := byId[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, missing, nil
+}
+
+// CompareJobs lines up the user's jobs side by side, with salaries
+// normalised to yearly amounts in the currency.
+func CompareJobs(ids []string, userId, currency string) ([]JobComparison, []string, error) {
+	if len(ids) > MaxCompareJobs {
+		return nil, nil, ErrTooManyCompareJobs
+	}
+
+	jobs, missing, err := GetUserJobsByIds(ids, userId)
+	if err != nil || len(missing) > 0 {
+		return nil, missing, err
+	}
+
+	rates, err := GetExchangeRateMap()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comparisons := make([]JobComparison, len(jobs))
+	for i, job := range jobs {
+		comparisons[i] = JobComparison{
+			ID:             job.ID,
+			Company:        job.Company,
+			Position:       job.Position,
+			Status:         job.Status,
+			JobType:        job.JobType,
+			WorkMode:       job.WorkMode,
+			JobLocation:    job.JobLocation,
+			ParsedLocation: job.ParsedLocation,
+			Salary:         job.salary(),
+			Normalized:     NormalizeSalary(job, currency, rates),
+			CreatedAt:      job.CreatedAt,
+		}
+	}
+
+	return comparisons, nil, nil
+}
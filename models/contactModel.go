@@ -0,0 +1,308 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"jobstar.com/api/db"
+)
+
+type ContactRole string
+
+const (
+	Recruiter     ContactRole = "recruiter"
+	HiringManager ContactRole = "hiring_manager"
+	Referrer      ContactRole = "referrer"
+)
+
+const contactColumns = "id, userId, name, email, phone, linkedInUrl, company, notes, createdAt"
+
+type Contact struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"userId"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Phone       string    `json:"phone"`
+	LinkedInURL string    `json:"linkedInUrl"`
+	Company     string    `json:"company"`
+	Notes       string    `json:"notes"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// JobContact is a contact as seen from a job, with the part they played.
+type JobContact struct {
+	Contact
+	Role ContactRole `json:"role"`
+}
+
+// ContactJob is a job as seen from a contact, with the part they played.
+type ContactJob struct {
+	Job
+	Role ContactRole `json:"role"`
+}
+
+// JobContactRequest is the payload for linking a contact to a job.
+type JobContactRequest struct {
+	ContactID string `json:"contactId"`
+	Role      string `json:"role" example:"recruiter"` // recruiter, hiring_manager or referrer
+}
+
+// IsValid checks if the role is valid
+func (r ContactRole) RoleIsValid() bool {
+	switch r {
+	case Recruiter, HiringManager, Referrer:
+		return true
+	}
+	return false
+}
+
+func (c Contact) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("please provide contact name")
+	}
+	if c.Email != "" {
+		if _, err := mail.ParseAddress(c.Email); err != nil {
+			return errors.New("invalid email address")
+		}
+	}
+	if c.LinkedInURL != "" {
+		link, err := url.ParseRequestURI(c.LinkedInURL)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || !isLinkedInHost(link.Hostname()) {
+			return errors.New("linkedInUrl must be a linkedin.com URL")
+		}
+	}
+	return nil
+}
+
+// isLinkedInHost matches linkedin.com and its subdomains, such as
+// www.linkedin.com, but not look-alikes such as evillinkedin.com.
+func isLinkedInHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return host == "linkedin.com" || strings.HasSuffix(host, ".linkedin.com")
+}
+
+func (c *Contact) Save() error {
+	query := `INSERT INTO contacts(userId, name, email, phone, linkedInUrl, company, notes, createdAt)
+	VALUES($1, $2, $3, $4, $5, $6, $7, NOW()) RETURNING id, createdAt`
+
+	err := db.DB.QueryRow(query, c.UserID, c.Name, c.Email, c.Phone, c.LinkedInURL, c.Company, c.Notes).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c Contact) Update() error {
+	query := `
+		UPDATE contacts
+		SET name=$1, email=$2, phone=$3, linkedInUrl=$4, company=$5, notes=$6
+		WHERE id=$7 AND userId=$8
+	`
+
+	result, err := db.DB.Exec(query, c.Name, c.Email, c.Phone, c.LinkedInURL, c.Company, c.Notes, c.ID, c.UserID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("contact not found")
+	}
+
+	return nil
+}
+
+func (c Contact) Delete() error {
+	_, err := db.DB.Exec("DELETE FROM contacts WHERE id=$1 AND userId=$2", c.ID, c.UserID)
+	return err
+}
+
+func scanContact(row interface{ Scan(...interface{}) error }, c *Contact) error {
+	return row.Scan(&c.ID, &c.UserID, &c.Name, &c.Email, &c.Phone, &c.LinkedInURL, &c.Company, &c.Notes, &c.CreatedAt)
+}
+
+func GetUserContactById(id, userId string) (*Contact, error) {
+	query := "SELECT " + contactColumns + " FROM contacts WHERE id=$1 AND userId=$2"
+
+	var contact Contact
+	err := scanContact(db.DB.QueryRow(query, id, userId), &contact)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("contact not found")
+		}
+		return nil, err
+	}
+
+	return &contact, nil
+}
+
+// escapeLike escapes the LIKE wildcards in user input so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// SearchContacts returns one page of the user's contacts whose name, email,
+// phone or company contains search, and the total number of matches.
+func SearchContacts(userId, search string, limit, offset int) ([]Contact, int, error) {
+	where := "userId = $1"
+	args := []interface{}{userId}
+	if search != "" {
+		where += " AND (name ILIKE $2 OR email ILIKE $2 OR phone ILIKE $2 OR company ILIKE $2)"
+		args = append(args, "%"+escapeLike(search)+"%")
+	}
+
+	var total int
+	err := db.DB.QueryRow("SELECT COUNT(*) FROM contacts WHERE "+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT " + contactColumns + " FROM contacts WHERE " + where + " ORDER BY name, id LIMIT " +
+		placeholder(len(args)+1) + " OFFSET " + placeholder(len(args)+2)
+	rows, err := db.DB.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var contacts []Contact
+	for rows.Next() {
+		var contact Contact
+		if err := scanContact(rows, &contact); err != nil {
+			return nil, 0, err
+		}
+		contacts = append(contacts, contact)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return contacts, total, nil
+}
+
+// GetUserContacts returns all of the user's contacts.
+func GetUserContacts(userId string) ([]Contact, error) {
+	rows, err := db.DB.Query("SELECT "+contactColumns+" FROM contacts WHERE userId = $1 ORDER BY name, id", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []Contact
+	for rows.Next() {
+		var contact Contact
+		if err := scanContact(rows, &contact); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+// LinkContact records that the contact played the given role on the job.
+// Linking the same contact in the same role twice is a no-op.
+func LinkContact(jobId, contactId string, role ContactRole) error {
+	query := `INSERT INTO job_contacts(jobId, contactId, role, createdAt) VALUES($1, $2, $3, NOW())
+	ON CONFLICT (jobId, contactId, role) DO NOTHING`
+	_, err := db.DB.Exec(query, jobId, contactId, role)
+	return err
+}
+
+// UnlinkContact removes the contact from the job. An empty role removes
+// every role the contact has on the job.
+func UnlinkContact(jobId, contactId string, role ContactRole) error {
+	query := "DELETE FROM job_contacts WHERE jobId=$1 AND contactId=$2 AND ($3 = '' OR role = $3)"
+
+	result, err := db.DB.Exec(query, jobId, contactId, string(role))
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("contact is not linked to this job")
+	}
+
+	return nil
+}
+
+func GetJobContacts(jobId string) ([]JobContact, error) {
+	query := `
+		SELECT c.id, c.userId, c.name, c.email, c.phone, c.linkedInUrl, c.company, c.notes, c.createdAt, jc.role
+		FROM job_contacts jc
+		JOIN contacts c ON c.id = jc.contactId
+		WHERE jc.jobId = $1
+		ORDER BY c.name, jc.role
+	`
+	rows, err := db.DB.Query(query, jobId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []JobContact
+	for rows.Next() {
+		var c JobContact
+		err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.Email, &c.Phone, &c.LinkedInURL, &c.Company, &c.Notes, &c.CreatedAt, &c.Role)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+// GetContactJobs lists every job of the user that the contact was linked to.
+func GetContactJobs(contactId, userId string) ([]ContactJob, error) {
+	query := `
+		SELECT ` + jobColumnsOf("j") + `, jc.role
+		FROM job_contacts jc
+		JOIN jobs j ON j.id = jc.jobId
+		WHERE jc.contactId = $1 AND j.createdBy = $2 AND j.deletedAt IS NULL
+		ORDER BY j.createdAt DESC
+	`
+	rows, err := db.DB.Query(query, contactId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []ContactJob
+	for rows.Next() {
+		var j ContactJob
+		err := scanJob(rows, &j.Job, &j.Role)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"jobstar.com/api/db"
+)
+
+// DigestSettings controls when the weekly digest email is sent to a user.
+type DigestSettings struct {
+	Enabled  bool   `json:"enabled"`
+	Day      int    `json:"day"`  // 0 = Sunday ... 6 = Saturday
+	Hour     int    `json:"hour"` // 0 - 23, in the user's timezone
+	Timezone string `json:"timezone"`
+}
+
+// DigestSubscriber is a user who has opted in to the weekly digest.
+type DigestSubscriber struct {
+	UserID     string
+	FirstName  string
+	Email      string
+	Timezone   string
+	Locale     string
+	Day        int
+	Hour       int
+	Token      string
+	LastSentAt *time.Time
+}
+
+// WeeklyDigest holds everything that goes into one digest email.
+type WeeklyDigest struct {
+	NewApplications    []Job
+	StatusChanges      []StatusChange
+	UpcomingInterviews []JobInterview
+	StaleApplications  []Job
+	Totals             map[string]int
+}
+
+func (s DigestSettings) Validate() error {
+	if s.Day < 0 || s.Day > 6 {
+		return errors.New("day must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	if s.Hour < 0 || s.Hour > 23 {
+		return errors.New("hour must be between 0 and 23")
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", s.Timezone)
+	}
+	return nil
+}
+
+func GetDigestSettings(userId string) (*DigestSettings, error) {
+	query := "SELECT digestEnabled, digestDay, digestHour, timezone FROM users WHERE id=$1"
+
+	var settings DigestSettings
+	err := db.DB.QueryRow(query, userId).Scan(&settings.Enabled, &settings.Day, &settings.Hour, &settings.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+func (s DigestSettings) Update(userId string) error {
+	// The unsubscribe token is created the first time a user opts in and kept
+	// afterwards so links in old emails keep working. The timezone belongs to
+	// the user's preferences and is not changed here.
+	query := `
+		UPDATE users
+		SET digestEnabled=$1, digestDay=$2, digestHour=$3,
+			digestToken = CASE WHEN digestToken = '' THEN encode(gen_random_bytes(32), 'hex') ELSE digestToken END
+		WHERE id=$4
+	`
+
+	stmt, err := db.DB.Prepare(query)
+	if err != nil {
+		log.Printf("Error preparing statement: %v", err)
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(s.Enabled, s.Day, s.Hour, userId)
+	if err != nil {
+		log.Printf("Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// DigestTokenIsValid reports whether the unsubscribe token belongs to a user.
+func DigestTokenIsValid(token string) (bool, error) {
+	var valid bool
+	err := db.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE digestToken=$1 AND digestToken <> '')", token).Scan(&valid)
+	return valid, err
+}
+
+// UnsubscribeDigest turns the digest off for whoever owns the token.
+func UnsubscribeDigest(token string) error {
+	query := "UPDATE users SET digestEnabled=FALSE WHERE digestToken=$1 AND digestToken <> ''"
+
+	result, err := db.DB.Exec(query, token)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("invalid unsubscribe token")
+	}
+
+	return nil
+}
+
+func GetDigestSubscribers() ([]DigestSubscriber, error) {
+	query := `
+		SELECT id, firstName, email, timezone, locale, digestDay, digestHour, digestToken, digestLastSentAt
+		FROM users
+		WHERE digestEnabled = TRUE AND isVerified = TRUE AND deletedAt IS NULL
+	`
+	rows, err := db.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscribers []DigestSubscriber
+	for rows.Next() {
+		var s DigestSubscriber
+		err := rows.Scan(&s.UserID, &s.FirstName, &s.Email, &s.Timezone, &s.Locale, &s.Day, &s.Hour, &s.Token, &s.LastSentAt)
+		if err != nil {
+			return nil, err
+		}
+		subscribers = append(subscribers, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subscribers, nil
+}
+
+func MarkDigestSent(userId string, sentAt time.Time) error {
+	_, err := db.DB.Exec("UPDATE users SET digestLastSentAt=$1 WHERE id=$2", sentAt, userId)
+	return err
+}
+
+// GetWeeklyDigest collects the user's activity in the week starting at since
+// and their interviews in the week after it. Pending applications created
+// before staleBefore that have not moved since then are reported as stale.
+func GetWeeklyDigest(userId string, since, staleBefore time.Time) (*WeeklyDigest, error) {
+	var digest WeeklyDigest
+	var err error
+
+	digest.NewApplications, err = queryJobs(`
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE createdBy = $1 AND deletedAt IS NULL AND createdAt >= $2
+		ORDER BY createdAt DESC
+	`, userId, since)
+	if err != nil {
+		log.Printf("Error fetching new applications: %v", err)
+		return nil, err
+	}
+
+	digest.StatusChanges, err = GetStatusChangesSince(userId, since, false)
+	if err != nil {
+		log.Printf("Error fetching status changes: %v", err)
+		return nil, err
+	}
+
+	digest.UpcomingInterviews, err = GetUpcomingInterviews(userId, since.AddDate(0, 0, 7), since.AddDate(0, 0, 14))
+	if err != nil {
+		log.Printf("Error fetching interviews: %v", err)
+		return nil, err
+	}
+
+	digest.StaleApplications, err = queryJobs(`
+		SELECT `+jobColumnsOf("j")+`
+		FROM jobs j
+		WHERE j.createdBy = $1 AND j.deletedAt IS NULL AND j.status = $2 AND j.createdAt < $3
+			AND NOT EXISTS (
+				SELECT 1 FROM job_status_changes c WHERE c.jobId = j.id AND c.changedAt >= $3
+			)
+		ORDER BY j.createdAt
+	`, userId, Pending, staleBefore)
+	if err != nil {
+		log.Printf("Error fetching stale applications: %v", err)
+		return nil, err
+	}
+
+	acceptedJobs, pendingJobs, declinedJobs, interviewJobs, err := CountStatusJobs(userId)
+	if err != nil {
+		return nil, err
+	}
+	digest.Totals = map[string]int{
+		"accepted":  acceptedJobs,
+		"pending":   pendingJobs,
+		"interview": interviewJobs,
+		"declined":  declinedJobs,
+	}
+
+	return &digest, nil
+}
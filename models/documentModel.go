@@ -0,0 +1,166 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"jobstar.com/api/db"
+)
+
+type DocumentKind string
+
+const (
+	Resume      DocumentKind = "resume"
+	CoverLetter DocumentKind = "cover_letter"
+	Offer       DocumentKind = "offer"
+	OtherDoc    DocumentKind = "other"
+)
+
+// ErrQuotaExceeded is returned when saving a document would take the user
+// over their storage quota.
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+var (
+	ErrDocumentTooLarge = errors.New("file is too large")
+	ErrDocumentEmpty    = errors.New("file is empty")
+)
+
+type Document struct {
+	ID          string       `json:"id"`
+	JobID       string       `json:"jobId"`
+	UserID      string       `json:"userId"`
+	Kind        DocumentKind `json:"kind"`
+	FileName    string       `json:"fileName"`
+	ContentType string       `json:"contentType"`
+	Size        int64        `json:"size"`
+	StorageKey  string       `json:"-"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+const documentColumns = "id, jobId, userId, kind, fileName, contentType, size, storageKey, createdAt"
+
+// IsValid checks if the document kind is valid
+func (k DocumentKind) KindIsValid() bool {
+	switch k {
+	case Resume, CoverLetter, Offer, OtherDoc:
+		return true
+	}
+	return false
+}
+
+// GetUserStorageUsed returns the total size in bytes of the user's documents.
+func GetUserStorageUsed(userId string) (int64, error) {
+	var used int64
+	err := db.DB.QueryRow("SELECT COALESCE(SUM(size), 0) FROM documents WHERE userId=$1", userId).Scan(&used)
+	return used, err
+}
+
+// CheckDocumentLimits checks a file of size bytes against the per-file limit
+// maxBytes and, given the bytes the user already has stored, their quota.
+func CheckDocumentLimits(size, used, maxBytes, quota int64) error {
+	if size > maxBytes {
+		return ErrDocumentTooLarge
+	}
+	if size <= 0 {
+		return ErrDocumentEmpty
+	}
+	if used+size > quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// SaveWithinQuota records the document, failing with ErrQuotaExceeded if the
+// user's documents would then take up more than quota bytes. The user row is
+// locked so concurrent uploads cannot both squeeze under the limit.
+func (d *Document) SaveWithinQuota(quota int64) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("SELECT id FROM users WHERE id=$1 FOR UPDATE", d.UserID)
+	if err != nil {
+		return err
+	}
+
+	var used int64
+	err = tx.QueryRow("SELECT COALESCE(SUM(size), 0) FROM documents WHERE userId=$1", d.UserID).Scan(&used)
+	if err != nil {
+		return err
+	}
+
+	if used+d.Size > quota {
+		return ErrQuotaExceeded
+	}
+
+	query := `INSERT INTO documents(jobId, userId, kind, fileName, contentType, size, storageKey, createdAt)
+	VALUES($1, $2, $3, $4, $5, $6, $7, NOW()) RETURNING id, createdAt`
+
+	err = tx.QueryRow(query, d.JobID, d.UserID, d.Kind, d.FileName, d.ContentType, d.Size, d.StorageKey).Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d Document) Delete() error {
+	_, err := db.DB.Exec("DELETE FROM documents WHERE id=$1 AND jobId=$2", d.ID, d.JobID)
+	return err
+}
+
+func scanDocument(row interface{ Scan(...interface{}) error }, d *Document) error {
+	return row.Scan(&d.ID, &d.JobID, &d.UserID, &d.Kind, &d.FileName, &d.ContentType, &d.Size, &d.StorageKey, &d.CreatedAt)
+}
+
+func GetJobDocument(jobId, documentId string) (*Document, error) {
+	query := "SELECT " + documentColumns + " FROM documents WHERE id=$1 AND jobId=$2"
+
+	var document Document
+	err := scanDocument(db.DB.QueryRow(query, documentId, jobId), &document)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("document not found")
+		}
+		return nil, err
+	}
+
+	return &document, nil
+}
+
+func GetJobDocuments(jobId string) ([]Document, error) {
+	query := "SELECT " + documentColumns + " FROM documents WHERE jobId=$1 ORDER BY createdAt DESC"
+	return queryDocuments(query, jobId)
+}
+
+// GetUserDocuments returns every document the user has uploaded.
+func GetUserDocuments(userId string) ([]Document, error) {
+	query := "SELECT " + documentColumns + " FROM documents WHERE userId=$1 ORDER BY createdAt DESC"
+	return queryDocuments(query, userId)
+}
+
+func queryDocuments(query string, args ...interface{}) ([]Document, error) {
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []Document
+	for rows.Next() {
+		var document Document
+		if err := scanDocument(rows, &document); err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckDocumentLimits(t *testing.T) {
+	const maxBytes, quota = 10 << 20, 100 << 20
+
+	tests := []struct {
+		name string
+		size int64
+		used int64
+		want error
+	}{
+		{"small file", 1024, 0, nil},
+		{"exactly the file limit", maxBytes, 0, nil},
+		{"over the file limit", maxBytes + 1, 0, ErrDocumentTooLarge},
+		{"empty file", 0, 0, ErrDocumentEmpty},
+		{"fills the quota exactly", 1 << 20, quota - 1<<20, nil},
+		{"one byte over the quota", 1<<20 + 1, quota - 1<<20, ErrQuotaExceeded},
+		{"quota already full", 1, quota, ErrQuotaExceeded},
+		{"file limit is checked before the quota", maxBytes + 1, quota, ErrDocumentTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckDocumentLimits(tt.size, tt.used, maxBytes, quota)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"jobstar.com/api/db"
+)
+
+// maxDuplicateMatches caps how many candidates a duplicate check reports.
+const maxDuplicateMatches = 5
+
+// DuplicateMatch is an existing job that looks like the one being added.
+// Score is how alike they are, from 0 to 1.
+type DuplicateMatch struct {
+	ID        string    `json:"id"`
+	Company   string    `json:"company"`
+	Position  string    `json:"position"`
+	Status    Status    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	Score     float64   `json:"score"`
+}
+
+type JobMergeRequest struct {
+	SourceID string `json:"sourceId" binding:"required"`
+}
+
+// DuplicateFinder compares new jobs against a user's existing ones. Two jobs
+// are duplicates when both their normalised company and position are at
+// least Threshold alike and they were added within Window of each other.
+type DuplicateFinder struct {
+	Threshold float64
+	Window    time.Duration
+	jobs      []duplicateCandidate
+}
+
+type duplicateCandidate struct {
+	match    DuplicateMatch
+	company  string
+	position string
+}
+
+// NewDuplicateFinder loads the user's jobs to check new ones against.
+func NewDuplicateFinder(userId string, threshold float64, window time.Duration) (*DuplicateFinder, error) {
+	rows, err := db.DB.Query("SELECT id, company, position, status, createdAt FROM jobs WHERE createdBy = $1 AND deletedAt IS NULL", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	finder := &DuplicateFinder{Threshold: threshold, Window: window}
+	for rows.Next() {
+		var match DuplicateMatch
+		if err := rows.Scan(&match.ID, &match.Company, &match.Position, &match.Status, &match.CreatedAt); err != nil {
+			return nil, err
+		}
+		finder.jobs = append(finder.jobs, duplicateCandidate{
+			match:    match,
+			company:  NormalizeCompanyName(match.Company),
+			position: normalizePosition(match.Position),
+		})
+	}
+
+	return finder, rows.Err()
+}
+
+// Find returns the existing jobs that look like a job for the position at
+// the company added at the given time, best match first.
+func (f *DuplicateFinder) Find(company, position string, at time.Time) []DuplicateMatch {
+	company = NormalizeCompanyName(company)
+	position = normalizePosition(position)
+
+	var matches []DuplicateMatch
+	for _, candidate := range f.jobs {
+		gap := at.Sub(candidate.match.CreatedAt)
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap > f.Window {
+			continue
+		}
+
+		companyScore := similarity(company, candidate.company)
+		positionScore := similarity(position, candidate.position)
+		if companyScore < f.Threshold || positionScore < f.Threshold {
+			continue
+		}
+
+		match := candidate.match
+		match.Score = math.Round((companyScore+positionScore)/2*100) / 100
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > maxDuplicateMatches {
+		matches = matches[:maxDuplicateMatches]
+	}
+
+	return matches
+}
+
+// companySuffixes are legal forms left off when comparing company names.
+var companySuffixes = map[string]bool{
+	"inc": true, "incorporated": true, "ltd": true, "limited": true, "llc": true, "llp": true,
+	"corp": true, "corporation": true, "co": true, "company": true, "plc": true, "gmbh": true,
+	"ag": true, "sa": true, "bv": true, "nv": true, "pty": true, "pvt": true,
+}
+
+// positionAbbreviations are spelled out when comparing positions.
+var positionAbbreviations = map[string]string{
+	"sr": "senior", "snr": "senior", "jr": "junior", "jnr": "junior",
+	"eng": "engineer", "engr": "engineer", "dev": "developer", "mgr": "manager",
+}
+
+// NormalizeCompanyName reduces a company name to a form that ignores case,
+// punctuation, spacing, a leading "The" and legal suffixes such as "Inc.".
+func NormalizeCompanyName(name string) string {
+	words := normalizedWords(name)
+	if len(words) > 1 && words[0] == "the" {
+		words = words[1:]
+	}
+	for len(words) > 1 && companySuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+func normalizePosition(position string) string {
+	words := normalizedWords(position)
+	for i, word := range words {
+		if full, ok := positionAbbreviations[word]; ok {
+			words[i] = full
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func normalizedWords(value string) []string {
+	return strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// similarity scores two strings from 0 (nothing alike) to 1 (equal) by
+// their edit distance relative to the longer one.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// MergeJobs folds the source job into the target: its notes, status
+// history, interviews, documents, contacts and tags move over, any fields
+// the target leaves empty are filled from the source, the target keeps the
+// earlier creation date and the source is deleted. Both must be the user's.
+func MergeJobs(targetId, sourceId, userId string) error {
+	if targetId == sourceId {
+		return errors.New("cannot merge a job into itself")
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT id FROM jobs WHERE id IN ($1, $2) AND createdBy = $3 AND deletedAt IS NULL FOR UPDATE
+		) owned
+	`, targetId, sourceId, userId).Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if locked != 2 {
+		return errors.New("job not found")
+	}
+
+	statements := []string{
+		"UPDATE job_notes SET jobId = $1 WHERE jobId = $2",
+		"UPDATE job_status_changes SET jobId = $1 WHERE jobId = $2",
+		"UPDATE interviews SET jobId = $1 WHERE jobId = $2",
+		"UPDATE documents SET jobId = $1 WHERE jobId = $2",
+		`INSERT INTO job_contacts(jobId, contactId, role, createdAt)
+			SELECT $1, contactId, role, createdAt FROM job_contacts WHERE jobId = $2
+			ON CONFLICT (jobId, contactId, role) DO NOTHING`,
+		`INSERT INTO job_tags(jobId, tagId)
+			SELECT $1, tagId FROM job_tags WHERE jobId = $2
+			ON CONFLICT (jobId, tagId) DO NOTHING`,
+		`UPDATE jobs t SET
+			workMode = COALESCE(NULLIF(t.workMode, ''), s.workMode),
+			salaryMin = CASE WHEN t.salaryMin IS NULL AND t.salaryMax IS NULL THEN s.salaryMin ELSE t.salaryMin END,
+			salaryMax = CASE WHEN t.salaryMin IS NULL AND t.salaryMax IS NULL THEN s.salaryMax ELSE t.salaryMax END,
+			salaryCurrency = CASE WHEN t.salaryMin IS NULL AND t.salaryMax IS NULL THEN s.salaryCurrency ELSE t.salaryCurrency END,
+			salaryPeriod = CASE WHEN t.salaryMin IS NULL AND t.salaryMax IS NULL THEN s.salaryPeriod ELSE t.salaryPeriod END,
+			postingUrl = COALESCE(NULLIF(t.postingUrl, ''), s.postingUrl),
+			description = COALESCE(NULLIF(t.description, ''), s.description),
+			deadline = COALESCE(t.deadline, s.deadline),
+			source = COALESCE(NULLIF(t.source, ''), s.source),
+			priority = COALESCE(t.priority, s.priority),
+			createdAt = LEAST(t.createdAt, s.createdAt)
+		FROM jobs s
+		WHERE t.id = $1 AND s.id = $2`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, targetId, sourceId); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("DELETE FROM jobs WHERE id = $1", sourceId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
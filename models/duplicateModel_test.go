@@ -0,0 +1,118 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNormalizeCompanyName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Google", "google"},
+		{"Google Inc.", "google"},
+		{"  GOOGLE,  inc ", "google"},
+		{"The Walt Disney Company", "walt disney"},
+		{"Acme Corp. Ltd.", "acme"},
+		{"Siemens AG", "siemens"},
+		{"Müller GmbH", "müller"},
+		{"AT&T Inc", "at t"},
+		{"The Company", "company"},
+		{"The", "the"},
+		{"Co", "co"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeCompanyName(tt.name); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePosition(t *testing.T) {
+	tests := []struct {
+		position string
+		want     string
+	}{
+		{"Sr. Software Eng.", "senior software engineer"},
+		{"Jr Dev", "junior developer"},
+		{"Engineering Mgr", "engineering manager"},
+		{"Senior Backend Engineer (Go)", "senior backend engineer go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.position, func(t *testing.T) {
+			if got := normalizePosition(tt.position); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"google", "google", 1},
+		{"", "", 1},
+		{"google", "", 0},
+		{"google", "gogle", 1 - 1.0/6},
+		{"kitten", "sitting", 1 - 3.0/7},
+		{"ab", "ba", 0},
+		{"müller", "muller", 1 - 1.0/6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if got := similarity(tt.b, tt.a); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("reversed: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuplicateFinderFind(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	candidate := func(id, company, position string, age time.Duration) duplicateCandidate {
+		return duplicateCandidate{
+			match:    DuplicateMatch{ID: id, Company: company, Position: position, CreatedAt: now.Add(-age)},
+			company:  NormalizeCompanyName(company),
+			position: normalizePosition(position),
+		}
+	}
+
+	finder := &DuplicateFinder{
+		Threshold: 0.8,
+		Window:    30 * 24 * time.Hour,
+		jobs: []duplicateCandidate{
+			candidate("exact", "Google Inc.", "Senior Software Engineer", time.Hour),
+			candidate("typo", "Gogle", "Sr Software Engineer", 48*time.Hour),
+			candidate("old", "Google", "Senior Software Engineer", 60*24*time.Hour),
+			candidate("other-role", "Google", "Product Manager", time.Hour),
+			candidate("other-company", "Stripe", "Senior Software Engineer", time.Hour),
+		},
+	}
+
+	matches := finder.Find("google", "Senior Software Eng", now)
+	if len(matches) != 2 {
+		t.Fatalf("got %d matches, want 2: %+v", len(matches), matches)
+	}
+	if matches[0].ID != "exact" || matches[1].ID != "typo" {
+		t.Errorf("got %s, %s, want exact, typo", matches[0].ID, matches[1].ID)
+	}
+	if matches[0].Score != 1 {
+		t.Errorf("exact match scored %v, want 1", matches[0].Score)
+	}
+	if matches[1].Score >= matches[0].Score {
+		t.Errorf("typo scored %v, want less than %v", matches[1].Score, matches[0].Score)
+	}
+}
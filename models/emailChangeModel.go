@@ -0,0 +1,159 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"jobstar.com/api/db"
+)
+
+var ErrEmailTaken = errors.New("email address is already in use")
+
+var ErrEmailUnchanged = errors.New("that is already your email address")
+
+var ErrInvalidEmailChangeToken = errors.New("invalid or expired confirmation link")
+
+type EmailChangeRequest struct {
+	NewEmail string `json:"newEmail" binding:"required" example:"new@example.com"`
+	Password string `json:"password" binding:"required"`
+}
+
+// EmailChange is a confirmed change of a user's email address.
+type EmailChange struct {
+	UserID    string
+	FirstName string
+	OldEmail  string
+	NewEmail  string
+}
+
+// Validate checks the new address is a plain email address.
+func (r *EmailChangeRequest) Validate() error {
+	r.NewEmail = strings.TrimSpace(r.NewEmail)
+	address, err := mail.ParseAddress(r.NewEmail)
+	if err != nil || address.Address != r.NewEmail {
+		return errors.New("please provide a valid email address")
+	}
+	return nil
+}
+
+// hashToken is how confirmation tokens are stored, so a leaked table cannot
+// be used to take over accounts.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// emailInUse checks whether another user has the address, ignoring case.
+func emailInUse(q queryer, email, userId string) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(email) = LOWER($1) AND id <> $2)", email, userId).Scan(&exists)
+	return exists, err
+}
+
+// RequestEmailChange records a change to newEmail that applies once the
+// token is confirmed, replacing any change already pending. It returns the
+// user's current address and first name for the notice sent there.
+func RequestEmailChange(userId, newEmail, token string, expiresAt time.Time) (string, string, error) {
+	var currentEmail, firstName string
+	err := db.DB.QueryRow("SELECT email, firstName FROM users WHERE id=$1 AND deletedAt IS NULL", userId).Scan(&currentEmail, &firstName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", errors.New("user not found")
+		}
+		return "", "", err
+	}
+	if strings.EqualFold(currentEmail, newEmail) {
+		return "", "", ErrEmailUnchanged
+	}
+
+	taken, err := emailInUse(db.DB, newEmail, userId)
+	if err != nil {
+		return "", "", err
+	}
+	if taken {
+		return "", "", ErrEmailTaken
+	}
+
+	query := `
+		INSERT INTO email_changes(userId, newEmail, tokenHash, expiresAt) VALUES($1, $2, $3, $4)
+		ON CONFLICT (userId) DO UPDATE
+		SET newEmail = EXCLUDED.newEmail, tokenHash = EXCLUDED.tokenHash, expiresAt = EXCLUDED.expiresAt, createdAt = NOW()
+	`
+	if _, err := db.DB.Exec(query, userId, newEmail, hashToken(token), expiresAt); err != nil {
+		return "", "", err
+	}
+
+	return currentEmail, firstName, nil
+}
+
+// ConfirmEmailChange applies the pending change the token belongs to. It
+// reports ErrEmailTaken when someone else has taken the address since the
+// change was requested, in which case the change is dropped.
+func ConfirmEmailChange(token string) (*EmailChange, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var change EmailChange
+	var expiresAt time.Time
+	err = tx.QueryRow(`
+		DELETE FROM email_changes e
+		USING users u
+		WHERE e.tokenHash = $1 AND u.id = e.userId AND u.deletedAt IS NULL
+		RETURNING e.userId, u.firstName, u.email, e.newEmail, e.expiresAt
+	`, hashToken(token)).Scan(&change.UserID, &change.FirstName, &change.OldEmail, &change.NewEmail, &expiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidEmailChangeToken
+		}
+		return nil, err
+	}
+	if time.Now().After(expiresAt) {
+		// Keep the expired change out of the way of the next request
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidEmailChangeToken
+	}
+
+	taken, err := emailInUse(tx, change.NewEmail, change.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !taken {
+		// The unique constraint still has the last word if another account
+		// takes the address between the check and the update
+		_, err = tx.Exec("UPDATE users SET email=$1 WHERE id=$2", change.NewEmail, change.UserID)
+		if isUniqueViolation(err) {
+			taken = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if taken {
+		tx.Rollback()
+		if _, err := db.DB.Exec("DELETE FROM email_changes WHERE userId=$1", change.UserID); err != nil {
+			return nil, err
+		}
+		return nil, ErrEmailTaken
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// CancelEmailChange drops the user's pending email change, if any.
+func CancelEmailChange(userId string) error {
+	_, err := db.DB.Exec("DELETE FROM email_changes WHERE userId=$1", userId)
+	return err
+}
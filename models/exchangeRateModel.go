@@ -0,0 +1,175 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"jobstar.com/api/db"
+)
+
+// BaseCurrency is the currency every exchange rate is quoted against: a rate
+// is how many units of the currency one unit of BaseCurrency buys.
+const BaseCurrency = "USD"
+
+// DefaultCurrency is the preferred currency of users who have not chosen one.
+const DefaultCurrency = BaseCurrency
+
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type ExchangeRateUpdate struct {
+	Rates map[string]float64 `json:"rates" binding:"required" example:"EUR:0.86,GBP:0.75"`
+}
+
+// exchangeRateFile is the layout of the rates file read at startup.
+type exchangeRateFile struct {
+	Base  string             `json:"base"`
+	AsOf  Date               `json:"asOf"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Validate checks every currency is an ISO 4217 code with a positive rate,
+// and that the base currency is left at 1.
+func (u *ExchangeRateUpdate) Validate() error {
+	if len(u.Rates) == 0 {
+		return errors.New("please provide rates")
+	}
+
+	normalized := make(map[string]float64, len(u.Rates))
+	for currency, rate := range u.Rates {
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if !currencyCodePattern.MatchString(currency) {
+			return fmt.Errorf("%q is not a 3-letter ISO 4217 code", currency)
+		}
+		if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return fmt.Errorf("rate for %s must be positive", currency)
+		}
+		if currency == BaseCurrency && rate != 1 {
+			return fmt.Errorf("rates are quoted against %s, so its rate must be 1", BaseCurrency)
+		}
+		normalized[currency] = rate
+	}
+	u.Rates = normalized
+
+	return nil
+}
+
+// Save stores the rates, adding currencies that are new and replacing the
+// rates of those already known.
+func (u *ExchangeRateUpdate) Save() error {
+	return saveExchangeRates(u.Rates, time.Now())
+}
+
+func saveExchangeRates(rates map[string]float64, asOf time.Time) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Only replace rates older than these, so a rates file does not undo a
+	// more recent update made through the API
+	query := `
+		INSERT INTO exchange_rates(currency, rate, updatedAt) VALUES($1, $2, $3)
+		ON CONFLICT (currency) DO UPDATE SET rate = EXCLUDED.rate, updatedAt = EXCLUDED.updatedAt
+		WHERE exchange_rates.updatedAt <= EXCLUDED.updatedAt
+	`
+	for currency, rate := range rates {
+		if _, err := tx.Exec(query, currency, rate, asOf); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// LoadExchangeRateFile reads the rates file and stores any rates newer than
+// the ones already in the database.
+func LoadExchangeRateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var file exchangeRateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("invalid exchange rates file: %w", err)
+	}
+	if file.Base != BaseCurrency {
+		return fmt.Errorf("exchange rates file must be quoted against %s, not %q", BaseCurrency, file.Base)
+	}
+	if file.AsOf.IsZero() {
+		return errors.New("exchange rates file needs an asOf date")
+	}
+
+	update := ExchangeRateUpdate{Rates: file.Rates}
+	if err := update.Validate(); err != nil {
+		return fmt.Errorf("invalid exchange rates file: %w", err)
+	}
+
+	return saveExchangeRates(update.Rates, file.AsOf.Time)
+}
+
+func GetExchangeRates() ([]ExchangeRate, error) {
+	rows, err := db.DB.Query("SELECT currency, rate, updatedAt FROM exchange_rates ORDER BY currency")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []ExchangeRate
+	for rows.Next() {
+		var rate ExchangeRate
+		if err := rows.Scan(&rate.Currency, &rate.Rate, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+// ExchangeRates maps currencies to their rate, for converting amounts.
+type ExchangeRates map[string]float64
+
+func GetExchangeRateMap() (ExchangeRates, error) {
+	list, err := GetExchangeRates()
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make(ExchangeRates, len(list))
+	for _, rate := range list {
+		rates[rate.Currency] = rate.Rate
+	}
+	return rates, nil
+}
+
+// Convert changes an amount from one currency to another. It reports false
+// when either currency has no rate.
+func (r ExchangeRates) Convert(amount float64, from, to string) (float64, bool) {
+	fromRate, ok := r[from]
+	if !ok {
+		return 0, false
+	}
+	toRate, ok := r[to]
+	if !ok {
+		return 0, false
+	}
+	return amount / fromRate * toRate, true
+}
+
+// ExchangeRateExists checks a currency can be converted.
+func ExchangeRateExists(currency string) (bool, error) {
+	var exists bool
+	err := db.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM exchange_rates WHERE currency = $1)", currency).Scan(&exists)
+	return exists, err
+}
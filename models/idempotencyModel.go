@@ -0,0 +1,102 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"jobstar.com/api/db"
+)
+
+// ErrIdempotencyKeyInUse is returned while the first request with a key is
+// still being handled.
+var ErrIdempotencyKeyInUse = errors.New("a request with this idempotency key is still in progress")
+
+// ErrIdempotencyKeyReused is returned when a key comes back with a different
+// request than the one it was first used for.
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+// idempotencyLockTimeout is how long a claimed key may go without a stored
+// response before we assume the request that claimed it died.
+const idempotencyLockTimeout = 5 * time.Minute
+
+// StoredResponse is the first response given to a request with an
+// idempotency key, replayed to any retry.
+type StoredResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// ClaimIdempotencyKey records that a request with the key has started. It
+// returns nil, nil when the caller should go ahead and handle the request,
+// the stored response when the request was already handled, or one of the
+// idempotency errors.
+func ClaimIdempotencyKey(userId, key, requestHash string, ttl time.Duration) (*StoredResponse, error) {
+	var claimed string
+	err := db.DB.QueryRow(`
+		INSERT INTO idempotency_keys(userId, key, requestHash, createdAt, expiresAt)
+		VALUES($1, $2, $3, NOW(), NOW() + make_interval(secs => $4))
+		ON CONFLICT (userId, key) DO UPDATE
+		SET requestHash = EXCLUDED.requestHash, status = NULL, contentType = NULL, body = NULL,
+			createdAt = EXCLUDED.createdAt, expiresAt = EXCLUDED.expiresAt
+		WHERE idempotency_keys.expiresAt <= NOW()
+			OR (idempotency_keys.status IS NULL AND idempotency_keys.createdAt <= NOW() - make_interval(secs => $5))
+		RETURNING userId
+	`, userId, key, requestHash, ttl.Seconds(), idempotencyLockTimeout.Seconds()).Scan(&claimed)
+	if err == nil {
+		return nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var storedHash string
+	var status sql.NullInt64
+	var contentType sql.NullString
+	var response StoredResponse
+	err = db.DB.QueryRow("SELECT requestHash, status, contentType, body FROM idempotency_keys WHERE userId=$1 AND key=$2", userId, key).
+		Scan(&storedHash, &status, &contentType, &response.Body)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Cleaned up between the two queries, so nobody holds it now
+			return ClaimIdempotencyKey(userId, key, requestHash, ttl)
+		}
+		return nil, err
+	}
+
+	if storedHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !status.Valid {
+		return nil, ErrIdempotencyKeyInUse
+	}
+
+	response.Status = int(status.Int64)
+	response.ContentType = contentType.String
+	return &response, nil
+}
+
+// SaveIdempotentResponse stores the response to the request that claimed the key.
+func SaveIdempotentResponse(userId, key string, response StoredResponse) error {
+	_, err := db.DB.Exec("UPDATE idempotency_keys SET status=$1, contentType=$2, body=$3 WHERE userId=$4 AND key=$5",
+		response.Status, response.ContentType, response.Body, userId, key)
+	return err
+}
+
+// ReleaseIdempotencyKey forgets a key whose request failed, so it can be
+// retried.
+func ReleaseIdempotencyKey(userId, key string) error {
+	_, err := db.DB.Exec("DELETE FROM idempotency_keys WHERE userId=$1 AND key=$2 AND status IS NULL", userId, key)
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys removes keys past their expiry and reports how
+// many went.
+func DeleteExpiredIdempotencyKeys() (int64, error) {
+	result, err := db.DB.Exec("DELETE FROM idempotency_keys WHERE expiresAt <= NOW()")
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,314 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"jobstar.com/api/db"
+)
+
+type InterviewOutcome string
+
+const (
+	OutcomePending   InterviewOutcome = "pending"
+	OutcomePassed    InterviewOutcome = "passed"
+	OutcomeFailed    InterviewOutcome = "failed"
+	OutcomeCancelled InterviewOutcome = "cancelled"
+)
+
+type JobInterview struct {
+	ID              string           `json:"id"`
+	JobID           string           `json:"jobId"`
+	ScheduledAt     time.Time        `json:"scheduledAt"`
+	Timezone        string           `json:"timezone"`
+	DurationMinutes int              `json:"durationMinutes"`
+	RoundName       string           `json:"roundName"`
+	Interviewer     string           `json:"interviewer"`
+	Location        string           `json:"location"`
+	VideoLink       string           `json:"videoLink"`
+	Outcome         InterviewOutcome `json:"outcome"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	UpdatedAt       time.Time        `json:"updatedAt"`
+	Revision        int              `json:"revision"`           // goes up with every change to the interview
+	Company         string           `json:"company,omitempty"`  // only set when listed across jobs
+	Position        string           `json:"position,omitempty"` // only set when listed across jobs
+}
+
+// InterviewRequest is the payload for creating or updating an interview.
+// ScheduledAt is either RFC 3339 or a local date-time ("2006-01-02T15:04")
+// interpreted in Timezone.
+type InterviewRequest struct {
+	ScheduledAt     string `json:"scheduledAt" example:"2024-09-10T14:00"`
+	Timezone        string `json:"timezone" example:"Europe/London"`
+	DurationMinutes int    `json:"durationMinutes" example:"60"`
+	RoundName       string `json:"roundName" example:"Technical screen"`
+	Interviewer     string `json:"interviewer" example:"Jane Doe"`
+	Location        string `json:"location" example:"1 Main St, London"`
+	VideoLink       string `json:"videoLink" example:"https://meet.example.com/abc"`
+	Outcome         string `json:"outcome" example:"pending"`
+}
+
+const interviewColumns = "id, jobId, scheduledAt, timezone, durationMinutes, roundName, interviewer, location, videoLink, outcome, createdAt, updatedAt, revision"
+
+// IsValid checks if the outcome is valid
+func (o InterviewOutcome) OutcomeIsValid() bool {
+	switch o {
+	case OutcomePending, OutcomePassed, OutcomeFailed, OutcomeCancelled:
+		return true
+	}
+	return false
+}
+
+var localDateTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"}
+
+// ToInterview validates the request and converts it to a JobInterview.
+func (r InterviewRequest) ToInterview() (*JobInterview, error) {
+	if r.ScheduledAt == "" {
+		return nil, errors.New("please provide scheduledAt")
+	}
+
+	if r.Timezone == "" {
+		r.Timezone = "UTC"
+	}
+	location, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", r.Timezone)
+	}
+
+	scheduledAt, err := time.Parse(time.RFC3339, r.ScheduledAt)
+	if err != nil {
+		parsed := false
+		for _, layout := range localDateTimeLayouts {
+			scheduledAt, err = time.ParseInLocation(layout, r.ScheduledAt, location)
+			if err == nil {
+				parsed = true
+				break
+			}
+		}
+		if !parsed {
+			return nil, errors.New("scheduledAt must be RFC 3339 or YYYY-MM-DDTHH:MM")
+		}
+	}
+
+	if r.DurationMinutes == 0 {
+		r.DurationMinutes = 60
+	}
+	if r.DurationMinutes < 0 || r.DurationMinutes > 24*60 {
+		return nil, errors.New("durationMinutes must be between 1 and 1440")
+	}
+
+	if r.Outcome == "" {
+		r.Outcome = string(OutcomePending)
+	}
+	outcome := InterviewOutcome(r.Outcome)
+	if !outcome.OutcomeIsValid() {
+		return nil, errors.New("invalid outcome")
+	}
+
+	if r.VideoLink != "" {
+		link, err := url.ParseRequestURI(r.VideoLink)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			return nil, errors.New("videoLink must be an http(s) URL")
+		}
+	}
+
+	return &JobInterview{
+		ScheduledAt:     scheduledAt,
+		Timezone:        r.Timezone,
+		DurationMinutes: r.DurationMinutes,
+		RoundName:       r.RoundName,
+		Interviewer:     r.Interviewer,
+		Location:        r.Location,
+		VideoLink:       r.VideoLink,
+		Outcome:         outcome,
+	}, nil
+}
+
+// EndsAt returns when the interview is expected to finish.
+func (i JobInterview) EndsAt() time.Time {
+	return i.ScheduledAt.Add(time.Duration(i.DurationMinutes) * time.Minute)
+}
+
+func (i *JobInterview) Save() error {
+	query := `INSERT INTO interviews(jobId, scheduledAt, timezone, durationMinutes, roundName, interviewer, location, videoLink, outcome, createdAt)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW()) RETURNING id, createdAt, updatedAt, revision`
+
+	err := db.DB.QueryRow(query, i.JobID, i.ScheduledAt, i.Timezone, i.DurationMinutes, i.RoundName, i.Interviewer,
+		i.Location, i.VideoLink, i.Outcome).Scan(&i.ID, &i.CreatedAt, &i.UpdatedAt, &i.Revision)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (i JobInterview) Update() error {
+	// A rescheduled interview gets a fresh reminder
+	query := `
+		UPDATE interviews
+		SET scheduledAt=$1, timezone=$2, durationMinutes=$3, roundName=$4, interviewer=$5, location=$6, videoLink=$7, outcome=$8,
+			reminderSentAt = CASE WHEN scheduledAt = $1 THEN reminderSentAt ELSE NULL END
+		WHERE id=$9 AND jobId=$10
+	`
+
+	result, err := db.DB.Exec(query, i.ScheduledAt, i.Timezone, i.DurationMinutes, i.RoundName, i.Interviewer,
+		i.Location, i.VideoLink, i.Outcome, i.ID, i.JobID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("interview not found")
+	}
+
+	return nil
+}
+
+func (i JobInterview) Delete() error {
+	_, err := db.DB.Exec("DELETE FROM interviews WHERE id=$1 AND jobId=$2", i.ID, i.JobID)
+	return err
+}
+
+func scanInterview(row interface{ Scan(...interface{}) error }, i *JobInterview) error {
+	return row.Scan(&i.ID, &i.JobID, &i.ScheduledAt, &i.Timezone, &i.DurationMinutes, &i.RoundName,
+		&i.Interviewer, &i.Location, &i.VideoLink, &i.Outcome, &i.CreatedAt, &i.UpdatedAt, &i.Revision)
+}
+
+func GetJobInterview(jobId, interviewId string) (*JobInterview, error) {
+	query := "SELECT " + interviewColumns + " FROM interviews WHERE id=$1 AND jobId=$2"
+
+	var interview JobInterview
+	err := scanInterview(db.DB.QueryRow(query, interviewId, jobId), &interview)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("interview not found")
+		}
+		return nil, err
+	}
+
+	return &interview, nil
+}
+
+func GetJobInterviews(jobId string) ([]JobInterview, error) {
+	query := "SELECT " + interviewColumns + " FROM interviews WHERE jobId=$1 ORDER BY scheduledAt"
+	return queryInterviews(query, jobId)
+}
+
+// GetUserInterviews returns every interview across the user's jobs, with the
+// company and position filled in. Interviews for jobs in the trash are only
+// included when includeTrashed is set.
+func GetUserInterviews(userId string, includeTrashed bool) ([]JobInterview, error) {
+	query := `
+		SELECT i.id, i.jobId, i.scheduledAt, i.timezone, i.durationMinutes, i.roundName, i.interviewer,
+			i.location, i.videoLink, i.outcome, i.createdAt, i.updatedAt, i.revision, j.company, j.position
+		FROM interviews i
+		JOIN jobs j ON j.id = i.jobId
+		WHERE j.createdBy = $1 AND ($2 OR j.deletedAt IS NULL)
+		ORDER BY i.scheduledAt
+	`
+	return queryInterviewsWithJob(query, userId, includeTrashed)
+}
+
+// GetUpcomingInterviews returns the user's interviews scheduled between from and to.
+func GetUpcomingInterviews(userId string, from, to time.Time) ([]JobInterview, error) {
+	query := `
+		SELECT i.id, i.jobId, i.scheduledAt, i.timezone, i.durationMinutes, i.roundName, i.interviewer,
+			i.location, i.videoLink, i.outcome, i.createdAt, i.updatedAt, i.revision, j.company, j.position
+		FROM interviews i
+		JOIN jobs j ON j.id = i.jobId
+		WHERE j.createdBy = $1 AND j.deletedAt IS NULL AND i.scheduledAt >= $2 AND i.scheduledAt < $3 AND i.outcome <> $4
+		ORDER BY i.scheduledAt
+	`
+	return queryInterviewsWithJob(query, userId, from, to, OutcomeCancelled)
+}
+
+func queryInterviews(query string, args ...interface{}) ([]JobInterview, error) {
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var interviews []JobInterview
+	for rows.Next() {
+		var interview JobInterview
+		if err := scanInterview(rows, &interview); err != nil {
+			return nil, err
+		}
+		interviews = append(interviews, interview)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return interviews, nil
+}
+
+func queryInterviewsWithJob(query string, args ...interface{}) ([]JobInterview, error) {
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var interviews []JobInterview
+	for rows.Next() {
+		var i JobInterview
+		err := rows.Scan(&i.ID, &i.JobID, &i.ScheduledAt, &i.Timezone, &i.DurationMinutes, &i.RoundName,
+			&i.Interviewer, &i.Location, &i.VideoLink, &i.Outcome, &i.CreatedAt, &i.UpdatedAt, &i.Revision, &i.Company, &i.Position)
+		if err != nil {
+			return nil, err
+		}
+		interviews = append(interviews, i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return interviews, nil
+}
+
+// GetCalendarToken returns the secret token for the user's interview feed,
+// creating one the first time it is requested.
+func GetCalendarToken(userId string) (string, error) {
+	query := `
+		UPDATE users
+		SET calendarToken = CASE WHEN calendarToken = '' THEN encode(gen_random_bytes(32), 'hex') ELSE calendarToken END
+		WHERE id=$1
+		RETURNING calendarToken
+	`
+
+	var token string
+	err := db.DB.QueryRow(query, userId).Scan(&token)
+	return token, err
+}
+
+// ResetCalendarToken replaces the feed token, invalidating the old feed URL.
+func ResetCalendarToken(userId string) (string, error) {
+	query := "UPDATE users SET calendarToken = encode(gen_random_bytes(32), 'hex') WHERE id=$1 RETURNING calendarToken"
+
+	var token string
+	err := db.DB.QueryRow(query, userId).Scan(&token)
+	return token, err
+}
+
+func GetUserIdByCalendarToken(token string) (string, error) {
+	var userId string
+	err := db.DB.QueryRow("SELECT id FROM users WHERE calendarToken=$1 AND calendarToken <> '' AND deletedAt IS NULL", token).Scan(&userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.New("calendar not found")
+		}
+		return "", err
+	}
+	return userId, nil
+}
@@ -3,10 +3,16 @@ package models
 import (
 	"database/sql"
 	"errors"
-	"log"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"jobstar.com/api/db"
+	"jobstar.com/api/geo"
 )
 
 type JobType string
@@ -28,20 +34,98 @@ const (
 	Pending   Status = "pending"
 )
 
+type SalaryPeriod string
+
+const (
+	PerHour  SalaryPeriod = "hour"
+	PerDay   SalaryPeriod = "day"
+	PerWeek  SalaryPeriod = "week"
+	PerMonth SalaryPeriod = "month"
+	PerYear  SalaryPeriod = "year"
+)
+
+type JobSource string
+
+const (
+	SourceLinkedIn    JobSource = "linkedin"
+	SourceReferral    JobSource = "referral"
+	SourceCompanySite JobSource = "company_site"
+	SourceJobBoard    JobSource = "job_board"
+	SourceRecruiter   JobSource = "recruiter"
+	SourceOther       JobSource = "other"
+)
+
+// WorkMode is where the work happens, independent of the contract JobType.
+type WorkMode string
+
+const (
+	Onsite     WorkMode = "onsite"
+	Hybrid     WorkMode = "hybrid"
+	RemoteWork WorkMode = "remote"
+)
+
+const (
+	MinPriority          = 1
+	MaxPriority          = 5
+	MaxDescriptionLength = 50000
+)
+
 type Job struct {
-	ID          string    `json:"id"`
-	Company     string    `json:"company"`
-	Position    string    `json:"position"`
-	JobLocation string    `json:"jobLocation"`
-	Status      Status    `json:"status"`
-	JobType     JobType   `json:"jobType"`
-	CreatedBy   string    `json:"createdBy"`
-	CreatedAt   time.Time `json:"createdAt"`
+	ID             string        `json:"id"`
+	Company        string        `json:"company"`
+	Position       string        `json:"position"`
+	JobLocation    string        `json:"jobLocation"`
+	Status         Status        `json:"status"`
+	JobType        JobType       `json:"jobType"`
+	WorkMode       WorkMode      `json:"workMode"`
+	SalaryMin      *int64        `json:"salaryMin"`
+	SalaryMax      *int64        `json:"salaryMax"`
+	SalaryCurrency string        `json:"salaryCurrency"`
+	SalaryPeriod   SalaryPeriod  `json:"salaryPeriod"`
+	PostingURL     string        `json:"postingUrl"`
+	Description    string        `json:"description"`
+	Deadline       *Date         `json:"deadline"`
+	Source         JobSource     `json:"source"`
+	Priority       *int          `json:"priority"`
+	Tags           []string      `json:"tags,omitempty"`
+	ParsedLocation *geo.Location `json:"parsedLocation"`
+	CompanyID      *string       `json:"companyId"`
+	CreatedBy      string        `json:"createdBy"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt"`
+	Version        int           `json:"version"`
+	ArchivedAt     *time.Time    `json:"archivedAt,omitempty"`
+	DeletedAt      *time.Time    `json:"deletedAt,omitempty"`
+}
+
+// JobFilter narrows down a user's job listing. Zero values are ignored.
+type JobFilter struct {
+	Status         Status
+	JobType        JobType
+	WorkMode       WorkMode
+	Source         JobSource
+	MinPriority    int
+	MinSalary      int64 // matches jobs whose range reaches at least this much
+	SalaryCurrency string
+	DeadlineAfter  *Date
+	DeadlineBefore *Date
+	Search         string        // matched against company and position
+	Tags           []string      // tag names, compared case-insensitively
+	TagMatch       string        // any (default) or all of Tags
+	Sort           string        // newest (default), oldest, deadline, priority or company
+	Archived       string        // false (default) hides archived jobs, true shows only them, all shows both
+	Deleted        string        // false (default) hides jobs in the trash, all shows them too
+	Near           *geo.Location // only jobs placed within RadiusKm of here
+	RadiusKm       float64
 }
 
-type MonthlyApplication struct {
-	Date  string `json:"date"`
-	Count int    `json:"count"`
+type StatusChange struct {
+	JobID      string    `json:"jobId"`
+	Company    string    `json:"company"`
+	Position   string    `json:"position"`
+	FromStatus Status    `json:"fromStatus"`
+	ToStatus   Status    `json:"toStatus"`
+	ChangedAt  time.Time `json:"changedAt"`
 }
 
 // IsValid checks if the JobType is valid
@@ -62,21 +146,398 @@ func (s Status) StatusIsValid() bool {
 	return false
 }
 
+// IsValid checks if the salary period is valid
+func (p SalaryPeriod) PeriodIsValid() bool {
+	switch p {
+	case PerHour, PerDay, PerWeek, PerMonth, PerYear:
+		return true
+	}
+	return false
+}
+
+// IsValid checks if the source is valid
+func (s JobSource) SourceIsValid() bool {
+	switch s {
+	case SourceLinkedIn, SourceReferral, SourceCompanySite, SourceJobBoard, SourceRecruiter, SourceOther:
+		return true
+	}
+	return false
+}
+
+// IsValid checks if the work mode is valid
+func (m WorkMode) WorkModeIsValid() bool {
+	switch m {
+	case Onsite, Hybrid, RemoteWork:
+		return true
+	}
+	return false
+}
+
+// Validate checks the required fields and enums of a job, defaulting the
+// status to pending, and then the optional details.
+func (j *Job) Validate() error {
+	if j.Company == "" {
+		return errors.New("please provide company name")
+	}
+	if j.JobLocation == "" {
+		return errors.New("please provide job location")
+	}
+	if j.Position == "" {
+		return errors.New("please provide position")
+	}
+	if j.JobType == "" {
+		return errors.New("please provide job type")
+	}
+	if !j.JobType.JobTypeIsValid() {
+		return errors.New("invalid job type")
+	}
+
+	if j.Status == "" {
+		j.Status = Pending
+	}
+	if !j.Status.StatusIsValid() {
+		return errors.New("invalid status")
+	}
+
+	return j.ValidateDetails()
+}
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidateDetails checks the optional salary, posting and planning fields.
+func (j *Job) ValidateDetails() error {
+	if (j.SalaryMin != nil && *j.SalaryMin < 0) || (j.SalaryMax != nil && *j.SalaryMax < 0) {
+		return errors.New("salary cannot be negative")
+	}
+	if j.SalaryMin != nil && j.SalaryMax != nil && *j.SalaryMin > *j.SalaryMax {
+		return errors.New("salaryMin cannot be greater than salaryMax")
+	}
+
+	j.SalaryCurrency = strings.ToUpper(strings.TrimSpace(j.SalaryCurrency))
+	if j.SalaryMin != nil || j.SalaryMax != nil {
+		if j.SalaryCurrency == "" {
+			return errors.New("please provide salaryCurrency")
+		}
+		if j.SalaryPeriod == "" {
+			return errors.New("please provide salaryPeriod")
+		}
+	}
+	if j.SalaryCurrency != "" && !currencyCodePattern.MatchString(j.SalaryCurrency) {
+		return errors.New("salaryCurrency must be a 3-letter ISO 4217 code")
+	}
+	if j.SalaryPeriod != "" && !j.SalaryPeriod.PeriodIsValid() {
+		return errors.New("invalid salaryPeriod")
+	}
+
+	if j.PostingURL != "" {
+		link, err := url.ParseRequestURI(j.PostingURL)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			return errors.New("postingUrl must be an http(s) URL")
+		}
+	}
+
+	if len(j.Description) > MaxDescriptionLength {
+		return errors.New("description is too long")
+	}
+
+	if j.Deadline != nil && j.Deadline.IsZero() {
+		j.Deadline = nil
+	}
+
+	if j.Source != "" && !j.Source.SourceIsValid() {
+		return errors.New("invalid source")
+	}
+
+	if j.Priority != nil && (*j.Priority < MinPriority || *j.Priority > MaxPriority) {
+		return fmt.Errorf("priority must be between %d and %d", MinPriority, MaxPriority)
+	}
+
+	if j.WorkMode != "" && !j.WorkMode.WorkModeIsValid() {
+		return errors.New("invalid workMode")
+	}
+
+	return nil
+}
+
+var jobFields = append([]string{
+	"id", "company", "position", "jobLocation", "status", "jobType", "workMode",
+	"salaryMin", "salaryMax", "salaryCurrency", "salaryPeriod", "postingUrl", "description",
+	"deadline", "source", "priority", "companyId", "createdBy", "createdAt", "updatedAt", "version", "archivedAt", "deletedAt",
+}, locationFields...)
+
+var jobColumns = strings.Join(jobFields, ", ")
+
+// jobColumnsOf returns the job columns qualified with a table alias, for joins.
+func jobColumnsOf(alias string) string {
+	qualified := make([]string, len(jobFields))
+	for i, field := range jobFields {
+		qualified[i] = alias + "." + field
+	}
+	return strings.Join(qualified, ", ")
+}
+
+// scanJob scans a row selected with jobColumns, followed by any extra columns.
+func scanJob(row interface{ Scan(...interface{}) error }, job *Job, extra ...interface{}) error {
+	var location locationColumns
+	dest := []interface{}{
+		&job.ID, &job.Company, &job.Position, &job.JobLocation, &job.Status, &job.JobType, &job.WorkMode,
+		&job.SalaryMin, &job.SalaryMax, &job.SalaryCurrency, &job.SalaryPeriod, &job.PostingURL, &job.Description,
+		&job.Deadline, &job.Source, &job.Priority, &job.CompanyID, &job.CreatedBy, &job.CreatedAt, &job.UpdatedAt, &job.Version, &job.ArchivedAt, &job.DeletedAt,
+	}
+	dest = append(dest, location.dest()...)
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	job.ParsedLocation = location.location()
+	return nil
+}
+
 func (j *Job) SaveJob() error {
-	query := `INSERT INTO jobs(company, position, jobLocation, status, jobType, createdBy, createdAt ) 
-	VALUES($1, $2, $3, $4, $5, $6, NOW()) RETURNING id`
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	j.CompanyID, err = resolveCompany(tx, j.CreatedBy, j.Company)
+	if err != nil {
+		return err
+	}
+	j.ParsedLocation = geo.Parse(j.JobLocation)
+
+	query := `INSERT INTO jobs(company, position, jobLocation, status, jobType, workMode, salaryMin, salaryMax,
+		salaryCurrency, salaryPeriod, postingUrl, description, deadline, source, priority, companyId, createdBy, createdAt,
+		locationCity, locationRegion, locationCountry, locationLat, locationLon)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, NOW(), $18, $19, $20, $21, $22)
+	RETURNING id, createdAt, updatedAt, version`
+
+	args := []interface{}{j.Company, j.Position, j.JobLocation, j.Status, j.JobType, j.WorkMode, j.SalaryMin, j.SalaryMax,
+		j.SalaryCurrency, j.SalaryPeriod, j.PostingURL, j.Description, j.Deadline, j.Source, j.Priority, j.CompanyID, j.CreatedBy}
 
 	// Use QueryRow to execute the query and retrieve the generated ID
-	err := db.DB.QueryRow(query, j.Company, j.Position, j.JobLocation, j.Status, j.JobType, j.CreatedBy).Scan(&j.ID)
+	err = tx.QueryRow(query, append(args, locationArgs(j.ParsedLocation)...)...).Scan(&j.ID, &j.CreatedAt, &j.UpdatedAt, &j.Version)
 	if err != nil {
 		return err
 	}
-	return nil
+	return tx.Commit()
 }
 
-func GetJobs(userId string) ([]Job, error) {
-	query := "SELECT id, company, position, jobLocation, status, jobType, createdBy, createdAt FROM jobs WHERE createdBy = $1"
-	rows, err := db.DB.Query(query, userId)
+// ImportJobs inserts the jobs and their tags in a single transaction, so
+// either every job is saved or none are. Jobs keep their CreatedAt and
+// ArchivedAt when set.
+func ImportJobs(jobs []Job) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO jobs(company, position, jobLocation, status, jobType, workMode, salaryMin, salaryMax,
+		salaryCurrency, salaryPeriod, postingUrl, description, deadline, source, priority, companyId, createdBy, createdAt,
+		archivedAt, locationCity, locationRegion, locationCountry, locationLat, locationLon)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
+	RETURNING id, createdAt, updatedAt, version`
+
+	for i := range jobs {
+		j := &jobs[i]
+		if j.CreatedAt.IsZero() {
+			j.CreatedAt = time.Now()
+		}
+
+		j.CompanyID, err = resolveCompany(tx, j.CreatedBy, j.Company)
+		if err != nil {
+			return err
+		}
+		j.ParsedLocation = geo.Parse(j.JobLocation)
+
+		args := []interface{}{j.Company, j.Position, j.JobLocation, j.Status, j.JobType, j.WorkMode, j.SalaryMin, j.SalaryMax,
+			j.SalaryCurrency, j.SalaryPeriod, j.PostingURL, j.Description, j.Deadline, j.Source, j.Priority, j.CompanyID, j.CreatedBy,
+			j.CreatedAt, j.ArchivedAt}
+
+		err = tx.QueryRow(query, append(args, locationArgs(j.ParsedLocation)...)...).Scan(&j.ID, &j.CreatedAt, &j.UpdatedAt, &j.Version)
+		if err != nil {
+			return err
+		}
+
+		if len(j.Tags) > 0 {
+			if err := attachTagsTx(tx, j.ID, j.CreatedBy, j.Tags); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetJobs lists the user's jobs matching the filter.
+func GetJobs(userId string, filter JobFilter) ([]Job, error) {
+	where, args := filter.where(userId)
+	query := "SELECT " + jobColumns + " FROM jobs WHERE " + where + " ORDER BY " + filter.orderBy()
+	return queryJobs(query, args...)
+}
+
+// StreamJobs calls fn for each of the user's jobs matching the filter, with
+// Tags filled in, without loading them all into memory. It stops at the
+// first error fn returns.
+func StreamJobs(userId string, filter JobFilter, fn func(Job) error) error {
+	where, args := filter.where(userId)
+	query := "SELECT " + jobColumns + `,
+		ARRAY(SELECT t.name FROM job_tags jt JOIN tags t ON t.id = jt.tagId WHERE jt.jobId = jobs.id ORDER BY LOWER(t.name))
+		FROM jobs WHERE ` + where + " ORDER BY " + filter.orderBy()
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var job Job
+		var tags []string
+		if err := scanJob(rows, &job, pq.Array(&tags)); err != nil {
+			return err
+		}
+		job.Tags = tags
+		if err := fn(job); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// where builds the WHERE clause for the filter, numbering placeholders from $1.
+func (f JobFilter) where(userId string) (string, []interface{}) {
+	conditions := []string{"createdBy = $1"}
+	args := []interface{}{userId}
+	if f.Deleted != "all" {
+		conditions = append(conditions, "deletedAt IS NULL")
+	}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", placeholder(len(args))))
+	}
+
+	if f.Status != "" {
+		add("status = ?", f.Status)
+	}
+	if f.JobType != "" {
+		add("jobType = ?", f.JobType)
+	}
+	if f.WorkMode != "" {
+		add("workMode = ?", f.WorkMode)
+	}
+	if f.Source != "" {
+		add("source = ?", f.Source)
+	}
+	if f.MinPriority > 0 {
+		add("priority >= ?", f.MinPriority)
+	}
+	if f.MinSalary > 0 {
+		add("COALESCE(salaryMax, salaryMin) >= ?", f.MinSalary)
+	}
+	if f.SalaryCurrency != "" {
+		add("salaryCurrency = ?", f.SalaryCurrency)
+	}
+	if f.DeadlineAfter != nil {
+		add("deadline >= ?", *f.DeadlineAfter)
+	}
+	if f.DeadlineBefore != nil {
+		add("deadline <= ?", *f.DeadlineBefore)
+	}
+	if f.Search != "" {
+		add("(company ILIKE ? OR position ILIKE ?)", "%"+escapeLike(f.Search)+"%")
+	}
+	switch f.Archived {
+	case "", "false":
+		conditions = append(conditions, "archivedAt IS NULL")
+	case "true":
+		conditions = append(conditions, "archivedAt IS NOT NULL")
+	}
+	if f.Near.HasCoordinates() {
+		args = append(args, *f.Near.Latitude, *f.Near.Longitude, f.RadiusKm)
+		latitude, longitude, radius := placeholder(len(args)-2), placeholder(len(args)-1), placeholder(len(args))
+		// Haversine distance, as in geo.DistanceKm. Rounding can push the
+		// ASIN argument just past 1 for antipodal points, which is an error
+		conditions = append(conditions, fmt.Sprintf(`locationLat IS NOT NULL AND 2 * %v * ASIN(LEAST(1, SQRT(
+			POWER(SIN(RADIANS(locationLat - %s) / 2), 2) +
+			COS(RADIANS(%s)) * COS(RADIANS(locationLat)) * POWER(SIN(RADIANS(locationLon - %s) / 2), 2)
+		))) <= %s`, geo.EarthRadiusKm, latitude, latitude, longitude, radius))
+	}
+	if len(f.Tags) > 0 {
+		names := make([]string, len(f.Tags))
+		for i, tag := range f.Tags {
+			names[i] = strings.ToLower(tag)
+		}
+
+		tagged := "id IN (SELECT jt.jobId FROM job_tags jt JOIN tags t ON t.id = jt.tagId WHERE LOWER(t.name) = ANY(?)"
+		if f.TagMatch == "all" {
+			tagged += fmt.Sprintf(" GROUP BY jt.jobId HAVING COUNT(DISTINCT LOWER(t.name)) = %d", len(uniqueStrings(names)))
+		}
+		add(tagged+")", pq.Array(names))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+func (f JobFilter) orderBy() string {
+	switch f.Sort {
+	case "oldest":
+		return "createdAt ASC, id"
+	case "deadline":
+		return "deadline ASC NULLS LAST, createdAt DESC, id"
+	case "priority":
+		return "priority DESC NULLS LAST, createdAt DESC, id"
+	case "company":
+		return "LOWER(company) ASC, createdAt DESC, id"
+	}
+	return "createdAt DESC, id"
+}
+
+// TagMatchIsValid checks if the tag match mode is any or all
+func (f JobFilter) TagMatchIsValid() bool {
+	switch f.TagMatch {
+	case "", "any", "all":
+		return true
+	}
+	return false
+}
+
+// ArchivedIsValid checks if the archived filter is false, true or all
+func (f JobFilter) ArchivedIsValid() bool {
+	switch f.Archived {
+	case "", "false", "true", "all":
+		return true
+	}
+	return false
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// SortIsValid checks if the sort order is one orderBy understands
+func (f JobFilter) SortIsValid() bool {
+	switch f.Sort {
+	case "", "newest", "oldest", "deadline", "priority", "company":
+		return true
+	}
+	return false
+}
+
+// queryJobs runs a query selecting jobColumns and scans every row.
+func queryJobs(query string, args ...interface{}) ([]Job, error) {
+	rows, err := db.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +546,7 @@ func GetJobs(userId string) ([]Job, error) {
 	var jobs []Job
 	for rows.Next() {
 		var job Job
-		err := rows.Scan(&job.ID, &job.Company, &job.Position, &job.JobLocation, &job.Status, &job.JobType, &job.CreatedBy, &job.CreatedAt)
+		err := scanJob(rows, &job)
 
 		if err != nil {
 			return nil, err
@@ -101,16 +562,50 @@ func GetJobs(userId string) ([]Job, error) {
 	return jobs, nil
 }
 
+// placeholder returns the n-th positional query parameter, e.g. $3.
+func placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// JobKey identifies a job application by company, position and the UTC date
+// it was created, for spotting duplicates on import.
+func JobKey(company, position string, createdAt time.Time) string {
+	return strings.ToLower(strings.TrimSpace(company)) + "|" +
+		strings.ToLower(strings.TrimSpace(position)) + "|" +
+		createdAt.UTC().Format("2006-01-02")
+}
+
+// GetJobKeys returns the JobKey of every job the user has.
+func GetJobKeys(userId string) (map[string]bool, error) {
+	rows, err := db.DB.Query("SELECT company, position, createdAt FROM jobs WHERE createdBy = $1 AND deletedAt IS NULL", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := map[string]bool{}
+	for rows.Next() {
+		var company, position string
+		var createdAt time.Time
+		if err := rows.Scan(&company, &position, &createdAt); err != nil {
+			return nil, err
+		}
+		keys[JobKey(company, position, createdAt)] = true
+	}
+
+	return keys, rows.Err()
+}
+
 func GetUserJobById(id, userId string) (*Job, error) {
-	query := "SELECT id, company, position, jobLocation, status, jobType, createdBy, createdAt FROM jobs WHERE id=$1 AND createdBy = $2"
+	query := "SELECT " + jobColumns + " FROM jobs WHERE id=$1 AND createdBy = $2 AND deletedAt IS NULL"
 	row := db.DB.QueryRow(query, id, userId)
 
 	var job Job
-	err := row.Scan(&job.ID, &job.Company, &job.Position, &job.JobLocation, &job.Status, &job.JobType, &job.CreatedBy, &job.CreatedAt)
+	err := scanJob(row, &job)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("job not found") // Job not found in the database
+			return nil, ErrJobNotFound // Job not found in the database
 		}
 		return nil, err // Other errors, e.g., connection issues, etc.
 	}
@@ -120,15 +615,15 @@ func GetUserJobById(id, userId string) (*Job, error) {
 }
 
 func GetJobById(id string) (*Job, error) {
-	query := "SELECT * FROM jobs WHERE id=$1"
+	query := "SELECT " + jobColumns + " FROM jobs WHERE id=$1 AND deletedAt IS NULL"
 	row := db.DB.QueryRow(query, id)
 
 	var job Job
-	err := row.Scan(&job.ID, &job.Company, &job.Position, &job.JobLocation, &job.Status, &job.JobType, &job.CreatedAt, &job.CreatedBy)
+	err := scanJob(row, &job)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("job not found") // Job not found in the database
+			return nil, ErrJobNotFound // Job not found in the database
 		}
 		return nil, err // Other errors, e.g., connection issues, etc.
 	}
@@ -137,136 +632,217 @@ func GetJobById(id string) (*Job, error) {
 	//pls note that we had to use pointer for event so that it can take a nil value when there is an error
 }
 
-func (j Job) Delete() error {
-	query := "DELETE FROM jobs WHERE id = $1"
-
-	stmt, err := db.DB.Prepare(query)
+// ErrJobNotFound is returned when a job does not exist or is in the trash.
+var ErrJobNotFound = errors.New("job not found")
+
+// Delete moves the job to the trash. It stays there, hidden from everything
+// else, until it is restored or purged. Unless expectedVersion is 0, the job
+// is only deleted if it is still at that version, otherwise
+// ErrVersionConflict is returned. ErrJobNotFound is returned if the job is
+// already gone.
+func (j *Job) Delete(expectedVersion int) error {
+	err := db.DB.QueryRow(`
+		UPDATE jobs SET deletedAt = NOW()
+		WHERE id = $1 AND deletedAt IS NULL AND ($2 = 0 OR version = $2)
+		RETURNING deletedAt
+	`, j.ID, expectedVersion).Scan(&j.DeletedAt)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return j.deleteMissed(expectedVersion)
+		}
 		return err
 	}
-	defer stmt.Close()
+	return nil
+}
 
-	_, err = stmt.Exec(j.ID)
+// deleteMissed tells why Delete matched no row: the job is still there at
+// another version, or it was deleted in the meantime.
+func (j *Job) deleteMissed(expectedVersion int) error {
+	if expectedVersion == 0 {
+		return ErrJobNotFound
+	}
 
+	var exists bool
+	err := db.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1 AND deletedAt IS NULL)", j.ID).Scan(&exists)
 	if err != nil {
 		return err
 	}
-	return nil
+	if exists {
+		return ErrVersionConflict
+	}
+	return ErrJobNotFound
 }
 
-func (job Job) Update(jobId string) error {
-	query := `
-		UPDATE jobs
-		SET company=$1, position=$2, jobLocation=$3, status=$4, jobType=$5
-		WHERE id=$6
-	`
+// RestoreJob takes one of the user's jobs back out of the trash. Jobs
+// deleted at or before cutoff are left for the purge worker, so a job is
+// never restored while its documents are being removed.
+func RestoreJob(id, userId string, cutoff time.Time) (*Job, error) {
+	query := "UPDATE jobs SET deletedAt = NULL WHERE id=$1 AND createdBy = $2 AND deletedAt > $3 RETURNING " + jobColumns
+	row := db.DB.QueryRow(query, id, userId, cutoff)
 
-	stmt, err := db.DB.Prepare(query)
+	var job Job
+	err := scanJob(row, &job)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("job not found in trash, or past its undo time")
+		}
+		return nil, err
+	}
 
+	return &job, nil
+}
+
+// GetTrashedJobs lists the user's deleted jobs, most recently deleted first.
+func GetTrashedJobs(userId string) ([]Job, error) {
+	query := "SELECT " + jobColumns + " FROM jobs WHERE createdBy = $1 AND deletedAt IS NOT NULL ORDER BY deletedAt DESC"
+	return queryJobs(query, userId)
+}
+
+// GetJobsDueForPurge returns the IDs of jobs that were deleted before cutoff.
+func GetJobsDueForPurge(cutoff time.Time) ([]string, error) {
+	rows, err := db.DB.Query("SELECT id FROM jobs WHERE deletedAt IS NOT NULL AND deletedAt <= $1", cutoff)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	defer stmt.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
 
-	result, err := stmt.Exec(job.Company, job.Position, job.JobLocation, job.Status, job.JobType, jobId)
+// PurgeJob hard-deletes a job deleted at or before cutoff along with
+// everything attached to it. Its documents must be removed from storage
+// before calling this.
+func PurgeJob(id string, cutoff time.Time) error {
+	result, err := db.DB.Exec("DELETE FROM jobs WHERE id = $1 AND deletedAt <= $2", id, cutoff)
 	if err != nil {
 		return err
 	}
 
-	// Check if any rows were affected
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("job with this ID not found") // No rows affected, meaning no job with the given ID was found
+		return errors.New("job is not due for purging")
 	}
 
-	return err
+	return nil
 }
 
-func CountStatusJobs(userId string) (int, int, int, int, error) {
-
-	var acceptedJobs, pendingJobs, declinedJobs, interviewJobs int
+// ErrVersionConflict is returned when a job has changed since the version a
+// write was based on.
+var ErrVersionConflict = errors.New("job has been changed by someone else")
 
-	// Count accepted jobs
-	query := "SELECT COUNT(*) FROM jobs WHERE createdBy = $1 AND status = $2"
-	err := db.DB.QueryRow(query, userId, "Accepted").Scan(&acceptedJobs)
+// Update overwrites the job's fields and sets job.Version to the new version.
+// Unless expectedVersion is 0, the job is only updated if it is still at that
+// version, otherwise ErrVersionConflict is returned.
+func (job *Job) Update(jobId string, expectedVersion int) error {
+	tx, err := db.DB.Begin()
 	if err != nil {
-		log.Printf("Error counting accepted jobs: %v", err)
-		return 0, 0, 0, 0, err
+		return err
 	}
-
-	// Count pending jobs
-	err = db.DB.QueryRow(query, userId, "pending").Scan(&pendingJobs)
+	defer tx.Rollback()
+
+	// Lock the row so the recorded status change matches what we overwrite
+	var previousStatus Status
+	var version int
+	var userId string
+	err = tx.QueryRow("SELECT status, version, createdBy FROM jobs WHERE id=$1 AND deletedAt IS NULL FOR UPDATE", jobId).
+		Scan(&previousStatus, &version, &userId)
 	if err != nil {
-		log.Printf("Error counting pending jobs: %v", err)
-		return 0, 0, 0, 0, err
+		if err == sql.ErrNoRows {
+			return errors.New("job with this ID not found")
+		}
+		return err
 	}
 
-	// Count declined jobs
-	err = db.DB.QueryRow(query, userId, "declined").Scan(&declinedJobs)
-	if err != nil { // Corrected error handling here
-		log.Printf("Error counting declined jobs: %v", err)
-		return 0, 0, 0, 0, err
+	if expectedVersion != 0 && version != expectedVersion {
+		return ErrVersionConflict
 	}
 
-	// Count interview jobs
-	err = db.DB.QueryRow(query, userId, "interview").Scan(&interviewJobs)
+	job.CompanyID, err = resolveCompany(tx, userId, job.Company)
 	if err != nil {
-		log.Printf("Error counting interview jobs: %v", err)
-		return 0, 0, 0, 0, err
+		return err
 	}
+	job.ParsedLocation = geo.Parse(job.JobLocation)
+
+	query := `
+		UPDATE jobs
+		SET company=$1, position=$2, jobLocation=$3, status=$4, jobType=$5, workMode=$6, salaryMin=$7, salaryMax=$8,
+			salaryCurrency=$9, salaryPeriod=$10, postingUrl=$11, description=$12, deadline=$13, source=$14, priority=$15,
+			companyId=$16, locationCity=$18, locationRegion=$19, locationCountry=$20, locationLat=$21, locationLon=$22
+		WHERE id=$17 AND deletedAt IS NULL
+		RETURNING version
+	`
 
-	return acceptedJobs, pendingJobs, declinedJobs, interviewJobs, nil
+	args := []interface{}{job.Company, job.Position, job.JobLocation, job.Status, job.JobType, job.WorkMode, job.SalaryMin,
+		job.SalaryMax, job.SalaryCurrency, job.SalaryPeriod, job.PostingURL, job.Description, job.Deadline, job.Source, job.Priority,
+		job.CompanyID, jobId}
+
+	err = tx.QueryRow(query, append(args, locationArgs(job.ParsedLocation)...)...).Scan(&job.Version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("job with this ID not found")
+		}
+		return err
+	}
+
+	if previousStatus != job.Status {
+		err = recordStatusChange(tx, jobId, previousStatus, job.Status)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func recordStatusChange(tx *sql.Tx, jobId string, from, to Status) error {
+	query := "INSERT INTO job_status_changes(jobId, fromStatus, toStatus, changedAt) VALUES($1, $2, $3, NOW())"
+	_, err := tx.Exec(query, jobId, from, to)
+	return err
 }
 
-func GetMonthlyApplications(userId string) ([]MonthlyApplication, error) {
+// GetStatusChangesSince returns the status changes on the user's jobs since
+// the given time. Changes on jobs in the trash are only included when
+// includeTrashed is set.
+func GetStatusChangesSince(userId string, since time.Time, includeTrashed bool) ([]StatusChange, error) {
 	query := `
-		SELECT 
-			EXTRACT(YEAR FROM createdAt) AS year, 
-			EXTRACT(MONTH FROM createdAt) AS month, 
-			COUNT(*) AS count
-		FROM jobs 
-		WHERE createdBy = $1
-		GROUP BY year, month
-		ORDER BY year DESC, month DESC
-		LIMIT 6;
+		SELECT c.jobId, j.company, j.position, c.fromStatus, c.toStatus, c.changedAt
+		FROM job_status_changes c
+		JOIN jobs j ON j.id = c.jobId
+		WHERE j.createdBy = $1 AND ($3 OR j.deletedAt IS NULL) AND c.changedAt >= $2
+		ORDER BY c.changedAt DESC
 	`
-
-	rows, err := db.DB.Query(query, userId)
+	rows, err := db.DB.Query(query, userId, since, includeTrashed)
 	if err != nil {
-		log.Printf("Error executing query: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var monthlyApplications []MonthlyApplication
-
+	var changes []StatusChange
 	for rows.Next() {
-		var year, month int
-		var count int
-
-		if err := rows.Scan(&year, &month, &count); err != nil {
-			log.Printf("Error scanning row: %v", err)
+		var change StatusChange
+		err := rows.Scan(&change.JobID, &change.Company, &change.Position, &change.FromStatus, &change.ToStatus, &change.ChangedAt)
+		if err != nil {
 			return nil, err
 		}
-
-		// Format the date as "Jan 2006"
-		date := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).Format("Jan 2006")
-
-		monthlyApplications = append(monthlyApplications, MonthlyApplication{
-			Date:  date,
-			Count: count,
-		})
+		changes = append(changes, change)
 	}
 
-	if err = rows.Err(); err != nil {
-		log.Printf("Error during row iteration: %v", err)
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return monthlyApplications, nil
+	return changes, nil
 }
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobDelete(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		expectedVersion int
+		deleted         bool // whether the UPDATE matches the row
+		exists          bool // whether the job is still there afterwards
+		want            error
+		checked         bool // whether existence is looked up
+	}{
+		{"deleted", 0, true, true, nil, false},
+		{"deleted at the expected version", 3, true, true, nil, false},
+		{"already gone", 0, false, false, ErrJobNotFound, false},
+		{"changed since", 3, false, true, ErrVersionConflict, true},
+		{"gone, with a version", 3, false, false, ErrJobNotFound, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := useStubDB(t, func(query string, args []driver.Value) stubResult {
+				if strings.Contains(query, "SELECT EXISTS") {
+					return stubResult{columns: []string{"exists"}, rows: [][]driver.Value{{tt.exists}}}
+				}
+				result := stubResult{columns: []string{"deletedAt"}}
+				if tt.deleted {
+					result.rows = [][]driver.Value{{deletedAt}}
+				}
+				return result
+			})
+
+			job := Job{ID: "a1"}
+			err := job.Delete(tt.expectedVersion)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && (job.DeletedAt == nil || !job.DeletedAt.Equal(deletedAt)) {
+				t.Errorf("deletedAt %v, want %v", job.DeletedAt, deletedAt)
+			}
+			if checked := len(stub.ran("SELECT EXISTS")) > 0; checked != tt.checked {
+				t.Errorf("looked up existence: %v, want %v", checked, tt.checked)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"database/sql"
+
+	"jobstar.com/api/db"
+	"jobstar.com/api/geo"
+)
+
+// DefaultRadiusKm is how far the near filter reaches when no radius is given.
+const DefaultRadiusKm = 50
+
+// MaxRadiusKm is half the Earth's circumference, beyond which every point
+// is in range anyway.
+const MaxRadiusKm = 20038
+
+// CountryStats counts a user's applications in one country. Country is
+// empty for jobs whose location could not be placed.
+type CountryStats struct {
+	Country     string `json:"country"`
+	CountryName string `json:"countryName"`
+	Count       int    `json:"count"`
+}
+
+// locationFields are the columns holding a parsed location, stored next to
+// the free text it was parsed from.
+var locationFields = []string{"locationCity", "locationRegion", "locationCountry", "locationLat", "locationLon"}
+
+// locationColumns receives the parsed location columns of a row.
+type locationColumns struct {
+	city, region, country sql.NullString
+	latitude, longitude   sql.NullFloat64
+}
+
+func (l *locationColumns) dest() []interface{} {
+	return []interface{}{&l.city, &l.region, &l.country, &l.latitude, &l.longitude}
+}
+
+func (l *locationColumns) location() *geo.Location {
+	if !l.country.Valid {
+		return nil
+	}
+
+	location := &geo.Location{
+		City:        l.city.String,
+		Region:      l.region.String,
+		Country:     l.country.String,
+		CountryName: geo.CountryName(l.country.String),
+	}
+	if l.latitude.Valid && l.longitude.Valid {
+		location.Latitude = &l.latitude.Float64
+		location.Longitude = &l.longitude.Float64
+	}
+	return location
+}
+
+// locationArgs returns the values to store for a parsed location, in the
+// order of locationFields.
+func locationArgs(location *geo.Location) []interface{} {
+	if location == nil {
+		return []interface{}{nil, nil, nil, nil, nil}
+	}
+
+	nullIfEmpty := func(value string) interface{} {
+		if value == "" {
+			return nil
+		}
+		return value
+	}
+	return []interface{}{
+		nullIfEmpty(location.City), nullIfEmpty(location.Region), location.Country, location.Latitude, location.Longitude,
+	}
+}
+
+// GetCountryStats counts the user's jobs by the country of their location,
+// most applications first.
+func GetCountryStats(userId string) ([]CountryStats, error) {
+	query := `
+		SELECT COALESCE(locationCountry, ''), COUNT(*)
+		FROM jobs
+		WHERE createdBy = $1 AND deletedAt IS NULL
+		GROUP BY locationCountry
+		ORDER BY COUNT(*) DESC, locationCountry NULLS LAST
+	`
+	rows, err := db.DB.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []CountryStats
+	for rows.Next() {
+		var stat CountryStats
+		if err := rows.Scan(&stat.Country, &stat.Count); err != nil {
+			return nil, err
+		}
+		if stat.Country == "" {
+			stat.CountryName = "Unknown"
+		} else {
+			stat.CountryName = geo.CountryName(stat.Country)
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// ParseUnparsedLocations fills in the parsed location of jobs and users
+// saved before locations were parsed, and reports how many rows it placed.
+// Text the gazetteer does not recognise is left as it is.
+func ParseUnparsedLocations() (int, error) {
+	tables := []struct{ table, textColumn string }{
+		{"jobs", "jobLocation"},
+		{"users", "location"},
+	}
+
+	parsed := 0
+	for _, t := range tables {
+		rows, err := db.DB.Query("SELECT id, " + t.textColumn + " FROM " + t.table + " WHERE locationCountry IS NULL AND " + t.textColumn + " <> ''")
+		if err != nil {
+			return parsed, err
+		}
+
+		locations := map[string]*geo.Location{}
+		for rows.Next() {
+			var id, text string
+			if err := rows.Scan(&id, &text); err != nil {
+				rows.Close()
+				return parsed, err
+			}
+			if location := geo.Parse(text); location != nil {
+				locations[id] = location
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return parsed, err
+		}
+
+		query := "UPDATE " + t.table + " SET locationCity=$1, locationRegion=$2, locationCountry=$3, locationLat=$4, locationLon=$5 WHERE id=$6"
+		for id, location := range locations {
+			if _, err := db.DB.Exec(query, append(locationArgs(location), id)...); err != nil {
+				return parsed, err
+			}
+			parsed++
+		}
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"jobstar.com/api/db"
+)
+
+const MaxNoteLength = 20000
+
+// JobNote is a markdown note attached to a job.
+type JobNote struct {
+	ID        string    `json:"id"`
+	JobID     string    `json:"jobId"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ActivityEntry is one event in a job's activity log. Details depends on
+// Type: the note body, the status transition or the interview summary.
+type ActivityEntry struct {
+	Type       string          `json:"type"` // created, note, status_change or interview
+	ID         string          `json:"id"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Details    json.RawMessage `json:"details"`
+}
+
+func (n *JobNote) Save() error {
+	query := `INSERT INTO job_notes(jobId, body, createdAt, updatedAt)
+	VALUES($1, $2, NOW(), NOW()) RETURNING id, createdAt, updatedAt`
+
+	err := db.DB.QueryRow(query, n.JobID, n.Body).Scan(&n.ID, &n.CreatedAt, &n.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (n *JobNote) Update() error {
+	query := "UPDATE job_notes SET body=$1, updatedAt=NOW() WHERE id=$2 AND jobId=$3 RETURNING createdAt, updatedAt"
+
+	err := db.DB.QueryRow(query, n.Body, n.ID, n.JobID).Scan(&n.CreatedAt, &n.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("note not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (n JobNote) Delete() error {
+	_, err := db.DB.Exec("DELETE FROM job_notes WHERE id=$1 AND jobId=$2", n.ID, n.JobID)
+	return err
+}
+
+func GetJobNote(jobId, noteId string) (*JobNote, error) {
+	query := "SELECT id, jobId, body, createdAt, updatedAt FROM job_notes WHERE id=$1 AND jobId=$2"
+
+	var note JobNote
+	err := db.DB.QueryRow(query, noteId, jobId).Scan(&note.ID, &note.JobID, &note.Body, &note.CreatedAt, &note.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("note not found")
+		}
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+func GetJobNotes(jobId string) ([]JobNote, error) {
+	query := "SELECT id, jobId, body, createdAt, updatedAt FROM job_notes WHERE jobId=$1 ORDER BY createdAt DESC"
+	return queryNotes(query, jobId)
+}
+
+// GetUserNotes returns the notes on every one of the user's jobs. Notes on
+// jobs in the trash are only included when includeTrashed is set.
+func GetUserNotes(userId string, includeTrashed bool) ([]JobNote, error) {
+	query := `
+		SELECT n.id, n.jobId, n.body, n.createdAt, n.updatedAt
+		FROM job_notes n
+		JOIN jobs j ON j.id = n.jobId
+		WHERE j.createdBy = $1 AND ($2 OR j.deletedAt IS NULL)
+		ORDER BY n.createdAt
+	`
+	return queryNotes(query, userId, includeTrashed)
+}
+
+func queryNotes(query string, args ...interface{}) ([]JobNote, error) {
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []JobNote
+	for rows.Next() {
+		var note JobNote
+		err := rows.Scan(&note.ID, &note.JobID, &note.Body, &note.CreatedAt, &note.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+// activityQuery merges every kind of job event into one chronological stream.
+const activityQuery = `
+	SELECT 'created' AS type, id, createdAt AS occurredAt,
+		json_build_object('company', company, 'position', position, 'status', status) AS details
+	FROM jobs WHERE id = $1
+	UNION ALL
+	SELECT 'note', id, createdAt,
+		json_build_object('body', body, 'updatedAt', updatedAt)
+	FROM job_notes WHERE jobId = $1
+	UNION ALL
+	SELECT 'status_change', id, changedAt,
+		json_build_object('fromStatus', fromStatus, 'toStatus', toStatus)
+	FROM job_status_changes WHERE jobId = $1
+	UNION ALL
+	SELECT 'interview', id, createdAt,
+		json_build_object('scheduledAt', scheduledAt, 'timezone', timezone, 'roundName', roundName, 'outcome', outcome)
+	FROM interviews WHERE jobId = $1
+`
+
+// GetJobActivity returns one page of the job's activity log, newest first,
+// along with the total number of entries.
+func GetJobActivity(jobId string, limit, offset int) ([]ActivityEntry, int, error) {
+	var total int
+	err := db.DB.QueryRow("SELECT COUNT(*) FROM ("+activityQuery+") activity", jobId).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT type, id, occurredAt, details FROM (" + activityQuery + ") activity ORDER BY occurredAt DESC, id LIMIT $2 OFFSET $3"
+	rows, err := db.DB.Query(query, jobId, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []ActivityEntry
+	for rows.Next() {
+		var entry ActivityEntry
+		var details []byte
+		err := rows.Scan(&entry.Type, &entry.ID, &entry.OccurredAt, &details)
+		if err != nil {
+			return nil, 0, err
+		}
+		entry.Details = json.RawMessage(details)
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
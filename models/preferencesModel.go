@@ -0,0 +1,238 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"jobstar.com/api/db"
+)
+
+// DefaultLocale is the locale of users who have not chosen one.
+const DefaultLocale = "en-US"
+
+// MaxTargetRoles caps how many roles a user can be looking for at once.
+const MaxTargetRoles = 20
+
+// MaxTargetRoleLength is the longest a target role may be.
+const MaxTargetRoleLength = 100
+
+// MaxReminderLeadHours is how far ahead an interview reminder can be sent.
+const MaxReminderLeadHours = 168
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+// Preferences are the settings that shape how the app works for a user:
+// times and dates are shown in their timezone and locale, salaries in their
+// preferred currency, and stats measured against what they are looking for.
+type Preferences struct {
+	Timezone          string                  `json:"timezone" example:"Europe/London"`
+	Locale            string                  `json:"locale" example:"en-GB"` // language, optionally with a region
+	PreferredCurrency string                  `json:"preferredCurrency" example:"GBP"`
+	Notifications     NotificationPreferences `json:"notifications"`
+	TargetRoles       []string                `json:"targetRoles" example:"Backend Engineer,Platform Engineer"`
+	DesiredSalary     *DesiredSalary          `json:"desiredSalary"`
+}
+
+// NotificationPreferences controls which emails a user gets and when.
+type NotificationPreferences struct {
+	WeeklyDigest       bool `json:"weeklyDigest" example:"true"`
+	DigestDay          int  `json:"digestDay" example:"1"`  // 0 = Sunday ... 6 = Saturday
+	DigestHour         int  `json:"digestHour" example:"8"` // 0 - 23, in the user's timezone
+	InterviewReminders bool `json:"interviewReminders" example:"true"`
+	ReminderLeadHours  int  `json:"reminderLeadHours" example:"24"` // how long before an interview the reminder is sent
+}
+
+// DesiredSalary is the least a user is looking to be paid.
+type DesiredSalary struct {
+	Min      int64        `json:"min" example:"70000"`
+	Currency string       `json:"currency" example:"GBP"`
+	Period   SalaryPeriod `json:"period" example:"year"`
+}
+
+// Yearly returns the desired salary as a yearly amount.
+func (s DesiredSalary) Yearly() float64 {
+	return float64(s.Min) * periodsPerYear[s.Period]
+}
+
+// Validate checks the preferences and tidies them up: codes are put in
+// their usual case and target roles are trimmed and de-duplicated.
+func (p *Preferences) Validate() error {
+	if p.Timezone == "" {
+		p.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", p.Timezone)
+	}
+
+	p.Locale = normalizeLocale(p.Locale)
+	if p.Locale == "" {
+		p.Locale = DefaultLocale
+	}
+	if !localePattern.MatchString(p.Locale) {
+		return errors.New("locale must be a language code, optionally with a region, such as en or en-GB")
+	}
+
+	p.PreferredCurrency = strings.ToUpper(strings.TrimSpace(p.PreferredCurrency))
+	if p.PreferredCurrency == "" {
+		p.PreferredCurrency = DefaultCurrency
+	}
+	if !currencyCodePattern.MatchString(p.PreferredCurrency) {
+		return errors.New("preferredCurrency must be a 3-letter ISO 4217 code")
+	}
+
+	n := p.Notifications
+	if err := (DigestSettings{Day: n.DigestDay, Hour: n.DigestHour, Timezone: p.Timezone}).Validate(); err != nil {
+		return err
+	}
+	if n.ReminderLeadHours == 0 {
+		p.Notifications.ReminderLeadHours = 24
+	}
+	if n.ReminderLeadHours < 0 || n.ReminderLeadHours > MaxReminderLeadHours {
+		return fmt.Errorf("reminderLeadHours must be between 1 and %d", MaxReminderLeadHours)
+	}
+
+	roles := []string{}
+	seen := map[string]bool{}
+	for _, role := range p.TargetRoles {
+		role = strings.Join(strings.Fields(role), " ")
+		if role == "" || seen[strings.ToLower(role)] {
+			continue
+		}
+		if len(role) > MaxTargetRoleLength {
+			return fmt.Errorf("target role %q is too long", role)
+		}
+		seen[strings.ToLower(role)] = true
+		roles = append(roles, role)
+	}
+	if len(roles) > MaxTargetRoles {
+		return fmt.Errorf("at most %d target roles are allowed", MaxTargetRoles)
+	}
+	p.TargetRoles = roles
+
+	if s := p.DesiredSalary; s != nil {
+		if s.Min <= 0 {
+			return errors.New("desiredSalary min must be positive")
+		}
+		s.Currency = strings.ToUpper(strings.TrimSpace(s.Currency))
+		if s.Currency == "" {
+			s.Currency = p.PreferredCurrency
+		}
+		if !currencyCodePattern.MatchString(s.Currency) {
+			return errors.New("desiredSalary currency must be a 3-letter ISO 4217 code")
+		}
+		if s.Period == "" {
+			s.Period = PerYear
+		}
+		if !s.Period.PeriodIsValid() {
+			return errors.New("desiredSalary period must be hour, day, week, month or year")
+		}
+	}
+
+	return nil
+}
+
+// Currencies returns the currencies the preferences refer to, which need an
+// exchange rate to be of any use.
+func (p Preferences) Currencies() []string {
+	currencies := []string{p.PreferredCurrency}
+	if p.DesiredSalary != nil && p.DesiredSalary.Currency != p.PreferredCurrency {
+		currencies = append(currencies, p.DesiredSalary.Currency)
+	}
+	return currencies
+}
+
+// normalizeLocale accepts "en_gb" or "EN-gb" as well as "en-GB".
+func normalizeLocale(locale string) string {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	language, region, found := strings.Cut(locale, "-")
+	if !found {
+		return strings.ToLower(language)
+	}
+	return strings.ToLower(language) + "-" + strings.ToUpper(region)
+}
+
+// preferenceFields are the users columns holding their preferences.
+const preferenceFields = `timezone, locale, preferredCurrency, digestEnabled, digestDay, digestHour,
+	interviewReminders, reminderLeadHours, targetRoles, desiredSalaryMin, desiredSalaryCurrency, desiredSalaryPeriod`
+
+// preferenceColumns receives the preference columns of a row.
+type preferenceColumns struct {
+	preferences    Preferences
+	salaryMin      sql.NullInt64
+	salaryCurrency sql.NullString
+	salaryPeriod   sql.NullString
+}
+
+func (c *preferenceColumns) dest() []interface{} {
+	p := &c.preferences
+	return []interface{}{&p.Timezone, &p.Locale, &p.PreferredCurrency,
+		&p.Notifications.WeeklyDigest, &p.Notifications.DigestDay, &p.Notifications.DigestHour,
+		&p.Notifications.InterviewReminders, &p.Notifications.ReminderLeadHours,
+		pq.Array(&p.TargetRoles), &c.salaryMin, &c.salaryCurrency, &c.salaryPeriod}
+}
+
+func (c *preferenceColumns) result() Preferences {
+	p := c.preferences
+	if p.TargetRoles == nil {
+		p.TargetRoles = []string{}
+	}
+	if c.salaryMin.Valid {
+		p.DesiredSalary = &DesiredSalary{
+			Min:      c.salaryMin.Int64,
+			Currency: c.salaryCurrency.String,
+			Period:   SalaryPeriod(c.salaryPeriod.String),
+		}
+	}
+	return p
+}
+
+func GetPreferences(userId string) (*Preferences, error) {
+	query := "SELECT " + preferenceFields + " FROM users WHERE id=$1 AND deletedAt IS NULL"
+
+	var columns preferenceColumns
+	err := db.DB.QueryRow(query, userId).Scan(columns.dest()...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	preferences := columns.result()
+	return &preferences, nil
+}
+
+func (p Preferences) Update(userId string) error {
+	// As with the digest settings, the unsubscribe token is created the
+	// first time the digest is turned on
+	query := `
+		UPDATE users
+		SET timezone=$1, locale=$2, preferredCurrency=$3, digestEnabled=$4, digestDay=$5, digestHour=$6,
+			interviewReminders=$7, reminderLeadHours=$8, targetRoles=$9,
+			desiredSalaryMin=$10, desiredSalaryCurrency=$11, desiredSalaryPeriod=$12,
+			digestToken = CASE WHEN digestToken = '' THEN encode(gen_random_bytes(32), 'hex') ELSE digestToken END
+		WHERE id=$13
+	`
+
+	var salaryMin, salaryCurrency, salaryPeriod interface{}
+	if s := p.DesiredSalary; s != nil {
+		salaryMin, salaryCurrency, salaryPeriod = s.Min, s.Currency, s.Period
+	}
+
+	n := p.Notifications
+	_, err := db.DB.Exec(query, p.Timezone, p.Locale, p.PreferredCurrency, n.WeeklyDigest, n.DigestDay, n.DigestHour,
+		n.InterviewReminders, n.ReminderLeadHours, pq.Array(p.TargetRoles),
+		salaryMin, salaryCurrency, salaryPeriod, userId)
+	if err != nil {
+		log.Printf("Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
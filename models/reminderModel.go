@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"jobstar.com/api/db"
+)
+
+// InterviewReminder is an upcoming interview whose reminder email is due,
+// with who to send it to.
+type InterviewReminder struct {
+	Interview JobInterview
+	UserID    string
+	FirstName string
+	Email     string
+	Timezone  string
+	Locale    string
+}
+
+// GetDueInterviewReminders returns the interviews starting within their
+// owner's reminder lead time that have not been reminded of yet. Cancelled
+// and already decided interviews are left out.
+func GetDueInterviewReminders(now time.Time) ([]InterviewReminder, error) {
+	query := `
+		SELECT i.id, i.jobId, i.scheduledAt, i.timezone, i.durationMinutes, i.roundName, i.interviewer,
+			i.location, i.videoLink, i.outcome, i.createdAt, i.updatedAt, i.revision, j.company, j.position,
+			u.id, u.firstName, u.email, u.timezone, u.locale
+		FROM interviews i
+		JOIN jobs j ON j.id = i.jobId
+		JOIN users u ON u.id = j.createdBy
+		WHERE u.interviewReminders = TRUE AND u.isVerified = TRUE AND u.deletedAt IS NULL AND j.deletedAt IS NULL
+			AND i.reminderSentAt IS NULL AND i.outcome = $2
+			AND i.scheduledAt > $1 AND i.scheduledAt <= $1 + make_interval(hours => u.reminderLeadHours)
+		ORDER BY i.scheduledAt
+	`
+	rows, err := db.DB.Query(query, now, OutcomePending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []InterviewReminder
+	for rows.Next() {
+		var r InterviewReminder
+		i := &r.Interview
+		err := rows.Scan(&i.ID, &i.JobID, &i.ScheduledAt, &i.Timezone, &i.DurationMinutes, &i.RoundName,
+			&i.Interviewer, &i.Location, &i.VideoLink, &i.Outcome, &i.CreatedAt, &i.UpdatedAt, &i.Revision, &i.Company, &i.Position,
+			&r.UserID, &r.FirstName, &r.Email, &r.Timezone, &r.Locale)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+func MarkInterviewReminderSent(interviewId string, sentAt time.Time) error {
+	_, err := db.DB.Exec("UPDATE interviews SET reminderSentAt=$1 WHERE id=$2", sentAt, interviewId)
+	return err
+}
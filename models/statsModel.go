@@ -0,0 +1,339 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"jobstar.com/api/db"
+)
+
+type Granularity string
+
+const (
+	ByDay     Granularity = "day"
+	ByWeek    Granularity = "week" // weeks start on Monday
+	ByMonth   Granularity = "month"
+	ByQuarter Granularity = "quarter"
+)
+
+const (
+	// DefaultStatsBuckets is how many periods stats cover when no range is given.
+	DefaultStatsBuckets = 6
+	// MaxStatsBuckets caps how many periods one stats request can return.
+	MaxStatsBuckets = 1000
+)
+
+// GranularityIsValid checks if the granularity is day, week, month or quarter
+func (g Granularity) GranularityIsValid() bool {
+	switch g {
+	case ByDay, ByWeek, ByMonth, ByQuarter:
+		return true
+	}
+	return false
+}
+
+// StatsRange is the span of calendar dates stats cover, both ends included,
+// read in Location and split into periods of Granularity.
+type StatsRange struct {
+	From        Date
+	To          Date
+	Granularity Granularity
+	Location    *time.Location
+}
+
+// ApplicationBucket counts the applications made in one period.
+type ApplicationBucket struct {
+	Date  string `json:"date"` // label such as "Jan 2006"
+	Start Date   `json:"start"`
+	End   Date   `json:"end"`
+	Count int    `json:"count"`
+}
+
+// MonthlyApplication counts the applications made in one month, in the
+// shape of the monthlyApplications stats that came before ApplicationBucket.
+type MonthlyApplication struct {
+	Date  string `json:"date"` // "Jan 2006"
+	Count int    `json:"count"`
+}
+
+// MonthlyApplications turns month buckets into monthlyApplications entries.
+func MonthlyApplications(buckets []ApplicationBucket) []MonthlyApplication {
+	months := make([]MonthlyApplication, len(buckets))
+	for i, bucket := range buckets {
+		months[i] = MonthlyApplication{Date: bucket.Date, Count: bucket.Count}
+	}
+	return months
+}
+
+// NewStatsRange fills in the missing ends of a range. Without to, it ends
+// today in location; without from, it starts DefaultStatsBuckets periods
+// before its end.
+func NewStatsRange(from, to *Date, granularity Granularity, location *time.Location, now time.Time) (StatsRange, error) {
+	r := StatsRange{Granularity: granularity, Location: location}
+
+	if to != nil {
+		r.To = *to
+	} else {
+		local := now.In(location)
+		r.To = Date{Time: time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)}
+	}
+
+	if from != nil {
+		r.From = *from
+	} else {
+		r.From = Date{Time: r.periodStart(r.To.Time)}
+		for i := 1; i < DefaultStatsBuckets; i++ {
+			r.From = Date{Time: r.periodStart(r.From.AddDate(0, 0, -1))}
+		}
+	}
+
+	if r.From.After(r.To.Time) {
+		return r, errors.New("from must not be after to")
+	}
+	if len(r.periods()) > MaxStatsBuckets {
+		return r, fmt.Errorf("range covers more than %d %ss", MaxStatsBuckets, granularity)
+	}
+
+	return r, nil
+}
+
+// Monthly returns the same range split into months.
+func (r StatsRange) Monthly() StatsRange {
+	r.Granularity = ByMonth
+	return r
+}
+
+// bounds returns the instants the range starts at and ends before.
+func (r StatsRange) bounds() (time.Time, time.Time) {
+	start := time.Date(r.From.Year(), r.From.Month(), r.From.Day(), 0, 0, 0, 0, r.Location)
+	end := time.Date(r.To.Year(), r.To.Month(), r.To.Day()+1, 0, 0, 0, 0, r.Location)
+	return start, end
+}
+
+// periodStart returns the first day of the period holding the date, the
+// same way Postgres' date_trunc does.
+func (r StatsRange) periodStart(date time.Time) time.Time {
+	switch r.Granularity {
+	case ByWeek:
+		offset := (int(date.Weekday()) + 6) % 7
+		return date.AddDate(0, 0, -offset)
+	case ByMonth:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case ByQuarter:
+		month := (date.Month()-1)/3*3 + 1
+		return time.Date(date.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return date
+}
+
+func (r StatsRange) nextPeriod(start time.Time) time.Time {
+	switch r.Granularity {
+	case ByWeek:
+		return start.AddDate(0, 0, 7)
+	case ByMonth:
+		return start.AddDate(0, 1, 0)
+	case ByQuarter:
+		return start.AddDate(0, 3, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func (r StatsRange) label(start time.Time) string {
+	switch r.Granularity {
+	case ByMonth:
+		return start.Format("Jan 2006")
+	case ByQuarter:
+		return fmt.Sprintf("Q%d %d", (start.Month()-1)/3+1, start.Year())
+	}
+	return start.Format(dateLayout)
+}
+
+// periods lists the first day of every period the range touches.
+func (r StatsRange) periods() []time.Time {
+	var starts []time.Time
+	for start := r.periodStart(r.From.Time); !start.After(r.To.Time); start = r.nextPeriod(start) {
+		starts = append(starts, start)
+		if len(starts) > MaxStatsBuckets {
+			break
+		}
+	}
+	return starts
+}
+
+// GetApplicationBuckets counts the user's applications in each period of
+// the range, including periods without any. The first and last periods only
+// count the days inside the range.
+func GetApplicationBuckets(userId string, r StatsRange) ([]ApplicationBucket, error) {
+	start, end := r.bounds()
+	query := `
+		SELECT date_trunc($2, createdAt AT TIME ZONE $3) AS period, COUNT(*)
+		FROM jobs
+		WHERE createdBy = $1 AND deletedAt IS NULL AND createdAt >= $4 AND createdAt < $5
+		GROUP BY period
+	`
+	rows, err := db.DB.Query(query, userId, string(r.Granularity), r.Location.String(), start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var period time.Time
+		var count int
+		if err := rows.Scan(&period, &count); err != nil {
+			return nil, err
+		}
+		counts[period.Format(dateLayout)] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	periods := r.periods()
+	buckets := make([]ApplicationBucket, len(periods))
+	for i, periodStart := range periods {
+		periodEnd := r.nextPeriod(periodStart).AddDate(0, 0, -1)
+		buckets[i] = ApplicationBucket{
+			Date:  r.label(periodStart),
+			Start: Date{Time: periodStart},
+			End:   Date{Time: periodEnd},
+			Count: counts[periodStart.Format(dateLayout)],
+		}
+	}
+
+	return buckets, nil
+}
+
+// GetStatusCounts counts the user's jobs by status, every status included.
+// With a range, only jobs added within it are counted.
+func GetStatusCounts(userId string, r *StatsRange) (map[Status]int, error) {
+	query := "SELECT status, COUNT(*) FROM jobs WHERE createdBy = $1 AND deletedAt IS NULL"
+	args := []interface{}{userId}
+	if r != nil {
+		start, end := r.bounds()
+		query += " AND createdAt >= $2 AND createdAt < $3"
+		args = append(args, start, end)
+	}
+	query += " GROUP BY status"
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[Status]int{Accepted: 0, Pending: 0, Declined: 0, Interview: 0}
+	for rows.Next() {
+		var status Status
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func CountStatusJobs(userId string) (int, int, int, int, error) {
+	counts, err := GetStatusCounts(userId, nil)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return counts[Accepted], counts[Pending], counts[Declined], counts[Interview], nil
+}
+
+// TargetRoleStats breaks down the jobs whose position mentions one of the
+// user's target roles by status.
+type TargetRoleStats struct {
+	Role     string         `json:"role"`
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"byStatus"`
+}
+
+// SalaryFit splits the user's jobs by whether the top of their salary range
+// reaches the desired salary, once both are yearly amounts in its currency.
+type SalaryFit struct {
+	Desired DesiredSalary `json:"desired"`
+	Meets   int           `json:"meets"`
+	Below   int           `json:"below"`
+	Unknown int           `json:"unknown"` // no salary given, or no exchange rate for it
+}
+
+// GetTargetRoleStats counts the user's jobs per target role and status,
+// matching roles anywhere in the position regardless of case. Roles come back
+// in the order given, including those with no jobs.
+func GetTargetRoleStats(userId string, roles []string) ([]TargetRoleStats, error) {
+	query := `
+		SELECT r.role, j.status, COUNT(j.id)
+		FROM unnest($2::text[]) WITH ORDINALITY AS r(role, ord)
+		LEFT JOIN jobs j ON j.createdBy = $1 AND j.deletedAt IS NULL AND strpos(LOWER(j.position), LOWER(r.role)) > 0
+		GROUP BY r.role, r.ord, j.status
+		ORDER BY r.ord
+	`
+	rows, err := db.DB.Query(query, userId, pq.Array(roles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []TargetRoleStats{}
+	for rows.Next() {
+		var role string
+		var status *Status
+		var count int
+		if err := rows.Scan(&role, &status, &count); err != nil {
+			return nil, err
+		}
+
+		if len(stats) == 0 || stats[len(stats)-1].Role != role {
+			stats = append(stats, TargetRoleStats{Role: role, ByStatus: map[string]int{}})
+		}
+		if status != nil {
+			current := &stats[len(stats)-1]
+			current.Total += count
+			current.ByStatus[string(*status)] = count
+		}
+	}
+
+	return stats, rows.Err()
+}
+
+// GetSalaryFit compares the salary of each of the user's jobs with the
+// salary they are looking for.
+func GetSalaryFit(userId string, desired DesiredSalary) (*SalaryFit, error) {
+	jobs, err := queryJobs("SELECT "+jobColumns+" FROM jobs WHERE createdBy = $1 AND deletedAt IS NULL", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := GetExchangeRateMap()
+	if err != nil {
+		return nil, err
+	}
+
+	fit := &SalaryFit{Desired: desired}
+	wanted := desired.Yearly()
+	for _, job := range jobs {
+		normalized := NormalizeSalary(job, desired.Currency, rates)
+		if normalized == nil {
+			fit.Unknown++
+			continue
+		}
+
+		top := normalized.Max
+		if top == nil {
+			top = normalized.Min
+		}
+		if float64(*top) >= wanted {
+			fit.Meets++
+		} else {
+			fit.Below++
+		}
+	}
+
+	return fit, nil
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func mustDate(t *testing.T, value string) Date {
+	t.Helper()
+	parsed, err := time.Parse(dateLayout, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Date{Time: parsed}
+}
+
+func TestStatsRangePeriods(t *testing.T) {
+	tests := []struct {
+		name        string
+		from, to    string
+		granularity Granularity
+		want        []string
+	}{
+		{"single day", "2024-03-05", "2024-03-05", ByDay, []string{"2024-03-05"}},
+		{"days across a month end", "2024-02-28", "2024-03-01", ByDay, []string{"2024-02-28", "2024-02-29", "2024-03-01"}},
+		{"weeks start on Monday", "2024-01-03", "2024-01-15", ByWeek, []string{"2024-01-01", "2024-01-08", "2024-01-15"}},
+		{"week starting on a Sunday", "2024-01-07", "2024-01-08", ByWeek, []string{"2024-01-01", "2024-01-08"}},
+		{"months from the 31st", "2024-01-31", "2024-03-01", ByMonth, []string{"2024-01-01", "2024-02-01", "2024-03-01"}},
+		{"quarters across a year end", "2023-11-15", "2024-04-01", ByQuarter, []string{"2023-10-01", "2024-01-01", "2024-04-01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := StatsRange{From: mustDate(t, tt.from), To: mustDate(t, tt.to), Granularity: tt.granularity, Location: time.UTC}
+
+			periods := r.periods()
+			got := make([]string, len(periods))
+			for i, start := range periods {
+				got[i] = start.Format(dateLayout)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStatsRangePeriodsCapped(t *testing.T) {
+	r := StatsRange{From: mustDate(t, "1900-01-01"), To: mustDate(t, "2024-01-01"), Granularity: ByDay, Location: time.UTC}
+	if got := len(r.periods()); got != MaxStatsBuckets+1 {
+		t.Errorf("got %d periods, want the loop to stop at %d", got, MaxStatsBuckets+1)
+	}
+}
+
+func TestNewStatsRange(t *testing.T) {
+	// Already 2 June in Tokyo, still 1 June in UTC
+	now := time.Date(2024, 6, 1, 20, 0, 0, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	r, err := NewStatsRange(nil, nil, ByMonth, tokyo, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.To.Format(dateLayout); got != "2024-06-02" {
+		t.Errorf("to = %s, want 2024-06-02", got)
+	}
+	if got := r.From.Format(dateLayout); got != "2024-01-01" {
+		t.Errorf("from = %s, want 2024-01-01", got)
+	}
+	if got := len(r.periods()); got != DefaultStatsBuckets {
+		t.Errorf("got %d periods, want %d", got, DefaultStatsBuckets)
+	}
+
+	from, to := mustDate(t, "2024-03-01"), mustDate(t, "2024-02-01")
+	if _, err := NewStatsRange(&from, &to, ByDay, time.UTC, now); err == nil {
+		t.Error("expected an error when from is after to")
+	}
+
+	from = mustDate(t, "2000-01-01")
+	to = mustDate(t, "2024-01-01")
+	if _, err := NewStatsRange(&from, &to, ByDay, time.UTC, now); err == nil {
+		t.Errorf("expected an error for more than %d days", MaxStatsBuckets)
+	}
+	if _, err := NewStatsRange(&from, &to, ByMonth, time.UTC, now); err != nil {
+		t.Errorf("unexpected error by month: %v", err)
+	}
+}
+
+func TestMonthlyApplications(t *testing.T) {
+	stub := useStubDB(t, func(query string, args []driver.Value) stubResult {
+		return stubResult{
+			columns: []string{"period", "count"},
+			rows:    [][]driver.Value{{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), int64(4)}},
+		}
+	})
+
+	// Weekly stats still give monthlyApplications for every month they touch
+	weekly := StatsRange{From: mustDate(t, "2024-02-12"), To: mustDate(t, "2024-04-03"), Granularity: ByWeek, Location: time.UTC}
+	buckets, err := GetApplicationBuckets("user-1", weekly.Monthly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []MonthlyApplication{{"Feb 2024", 0}, {"Mar 2024", 4}, {"Apr 2024", 0}}
+	if got := MonthlyApplications(buckets); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if queries := stub.ran("date_trunc"); len(queries) != 1 || queries[0].args[1] != "month" {
+		t.Errorf("queried %v", queries)
+	}
+	if weekly.Granularity != ByWeek {
+		t.Error("Monthly changed the original range")
+	}
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"jobstar.com/api/db"
+)
+
+// stubDB stands in for Postgres in model tests. Each statement is passed to
+// handle, which answers it like the real table would; statements and
+// transaction outcomes are recorded for the test to check.
+type stubDB struct {
+	handle func(query string, args []driver.Value) stubResult
+
+	mu         sync.Mutex
+	statements []stubStatement
+	commits    int
+	rollbacks  int
+}
+
+type stubStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// stubResult is the answer to one statement: rows for a query, or the
+// number of rows affected for anything else. err fails the statement.
+type stubResult struct {
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+	err      error
+}
+
+// useStubDB points db.DB at a stubDB for the rest of the test.
+func useStubDB(t *testing.T, handle func(query string, args []driver.Value) stubResult) *stubDB {
+	t.Helper()
+	stub := &stubDB{handle: handle}
+	previous := db.DB
+	db.DB = sql.OpenDB(stub)
+	t.Cleanup(func() {
+		db.DB.Close()
+		db.DB = previous
+	})
+	return stub
+}
+
+// ran returns the statements run so far that contain fragment.
+func (s *stubDB) ran(fragment string) []stubStatement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []stubStatement
+	for _, statement := range s.statements {
+		if strings.Contains(statement.query, fragment) {
+			matched = append(matched, statement)
+		}
+	}
+	return matched
+}
+
+func (s *stubDB) run(query string, named []driver.NamedValue) stubResult {
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	s.mu.Lock()
+	s.statements = append(s.statements, stubStatement{query: query, args: args})
+	s.mu.Unlock()
+	return s.handle(query, args)
+}
+
+func (s *stubDB) Connect(context.Context) (driver.Conn, error) { return stubConn{s}, nil }
+func (s *stubDB) Driver() driver.Driver                        { return stubDriver{s} }
+
+type stubDriver struct{ s *stubDB }
+
+func (d stubDriver) Open(string) (driver.Conn, error) { return stubConn(d), nil }
+
+type stubConn struct{ s *stubDB }
+
+func (c stubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("stubdb: prepared statements are not supported")
+}
+func (c stubConn) Close() error              { return nil }
+func (c stubConn) Begin() (driver.Tx, error) { return stubTx(c), nil }
+
+func (c stubConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result := c.s.run(query, args)
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &stubRows{columns: result.columns, rows: result.rows}, nil
+}
+
+func (c stubConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result := c.s.run(query, args)
+	if result.err != nil {
+		return nil, result.err
+	}
+	return driver.RowsAffected(result.affected), nil
+}
+
+type stubTx struct{ s *stubDB }
+
+func (t stubTx) Commit() error {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	t.s.commits++
+	return nil
+}
+
+func (t stubTx) Rollback() error {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	t.s.rollbacks++
+	return nil
+}
+
+type stubRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *stubRows) Columns() []string { return r.columns }
+func (r *stubRows) Close() error      { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
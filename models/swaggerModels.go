@@ -40,15 +40,45 @@ type UserRegisterRequest struct {
 }
 
 type UserUpdateRequest struct {
-	FirstName string `json:"firstName"`
-	LastName  string `json:"lastName"`
-	Location  string `json:"location"`
+	FirstName         string `json:"firstName"`
+	LastName          string `json:"lastName"`
+	Location          string `json:"location"`
+	PreferredCurrency string `json:"preferredCurrency" example:"EUR"`
 }
 
 type JobRequest struct {
-	Company     string `json:"company"`
-	Position    string `json:"position"`
-	JobLocation string `json:"jobLocation"`
-	Status      string `json:"status"`
-	JobType     string `json:"jobType"`
+	Company        string `json:"company"`
+	Position       string `json:"position"`
+	JobLocation    string `json:"jobLocation"`
+	Status         string `json:"status"`
+	JobType        string `json:"jobType"`
+	WorkMode       string `json:"workMode" example:"hybrid"` // onsite, hybrid or remote
+	SalaryMin      int64  `json:"salaryMin" example:"60000"`
+	SalaryMax      int64  `json:"salaryMax" example:"75000"`
+	SalaryCurrency string `json:"salaryCurrency" example:"GBP"`                     // ISO 4217
+	SalaryPeriod   string `json:"salaryPeriod" example:"year"`                      // hour, day, week, month or year
+	PostingURL     string `json:"postingUrl" example:"https://example.com/jobs/42"` // link to the job ad
+	Description    string `json:"description"`
+	Deadline       string `json:"deadline" example:"2024-09-30"` // YYYY-MM-DD
+	Source         string `json:"source" example:"linkedin"`     // linkedin, referral, company_site, job_board, recruiter or other
+	Priority       int    `json:"priority" example:"4"`          // 1 (low) to 5 (dream job)
+}
+
+type DigestSettingsRequest struct {
+	Enabled bool `json:"enabled" example:"true"`
+	Day     int  `json:"day" example:"1"`  // 0 = Sunday ... 6 = Saturday
+	Hour    int  `json:"hour" example:"8"` // 0 - 23, in your timezone
+}
+
+type NoteRequest struct {
+	Body string `json:"body" example:"Spoke to the recruiter, **second round** next week."`
+}
+
+type ContactRequest struct {
+	Name        string `json:"name" example:"Ada Obi"`
+	Email       string `json:"email" example:"ada@example.com"`
+	Phone       string `json:"phone" example:"+234 800 000 0000"`
+	LinkedInURL string `json:"linkedInUrl" example:"https://www.linkedin.com/in/ada-obi"`
+	Company     string `json:"company" example:"Acme"`
+	Notes       string `json:"notes"`
 }
@@ -0,0 +1,351 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"jobstar.com/api/db"
+)
+
+const MaxTagLength = 50
+
+// ErrTagExists is returned when a user already has a tag with the same name,
+// ignoring case.
+var ErrTagExists = errors.New("a tag with this name already exists")
+
+type Tag struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Name      string    `json:"name"`
+	JobCount  int       `json:"jobCount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type TagRequest struct {
+	Name string `json:"name" binding:"required" example:"fintech"`
+}
+
+type TagMergeRequest struct {
+	TargetID string `json:"targetId" binding:"required"`
+}
+
+type JobTagsRequest struct {
+	Tags []string `json:"tags" binding:"required" example:"fintech,remote-first"`
+}
+
+// TagStats breaks down the jobs carrying one tag by status.
+type TagStats struct {
+	Tag      string         `json:"tag"`
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"byStatus"`
+}
+
+// NormalizeTagName trims the name and checks it can be used as a tag. Commas
+// are not allowed because tag filters are comma separated.
+func NormalizeTagName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", errors.New("please provide tag name")
+	}
+	if len(name) > MaxTagLength {
+		return "", errors.New("tag name is too long")
+	}
+	if strings.Contains(name, ",") {
+		return "", errors.New("tag name cannot contain commas")
+	}
+	return name, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func (t *Tag) Save() error {
+	query := "INSERT INTO tags(userId, name, createdAt) VALUES($1, $2, NOW()) RETURNING id, createdAt"
+
+	err := db.DB.QueryRow(query, t.UserID, t.Name).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrTagExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (t Tag) Rename(name string) error {
+	result, err := db.DB.Exec("UPDATE tags SET name=$1 WHERE id=$2 AND userId=$3", name, t.ID, t.UserID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrTagExists
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("tag not found")
+	}
+
+	return nil
+}
+
+func (t Tag) Delete() error {
+	_, err := db.DB.Exec("DELETE FROM tags WHERE id=$1 AND userId=$2", t.ID, t.UserID)
+	return err
+}
+
+// MergeInto moves every job carrying this tag over to the target tag and
+// then deletes this tag.
+func (t Tag) MergeInto(targetId string) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO job_tags(jobId, tagId)
+		SELECT jobId, $1 FROM job_tags WHERE tagId = $2
+		ON CONFLICT (jobId, tagId) DO NOTHING
+	`, targetId, t.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM tags WHERE id=$1 AND userId=$2", t.ID, t.UserID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func GetUserTagById(id, userId string) (*Tag, error) {
+	query := `
+		SELECT t.id, t.userId, t.name, t.createdAt, (SELECT COUNT(*) FROM job_tags jt WHERE jt.tagId = t.id)
+		FROM tags t
+		WHERE t.id=$1 AND t.userId=$2
+	`
+
+	var tag Tag
+	err := db.DB.QueryRow(query, id, userId).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt, &tag.JobCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("tag not found")
+		}
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+func GetUserTags(userId string) ([]Tag, error) {
+	query := `
+		SELECT t.id, t.userId, t.name, t.createdAt, COUNT(jt.jobId)
+		FROM tags t
+		LEFT JOIN (job_tags jt JOIN jobs j ON j.id = jt.jobId AND j.deletedAt IS NULL) ON jt.tagId = t.id
+		WHERE t.userId = $1
+		GROUP BY t.id
+		ORDER BY LOWER(t.name)
+	`
+	rows, err := db.DB.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		var tag Tag
+		err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt, &tag.JobCount)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// AttachTags adds the named tags to the job, creating any the user does not
+// have yet. Names must already be normalised with NormalizeTagName.
+func AttachTags(jobId, userId string, names []string) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := attachTagsTx(tx, jobId, userId, names); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func attachTagsTx(tx *sql.Tx, jobId, userId string, names []string) error {
+	tagIds, err := upsertTagsTx(tx, userId, names)
+	if err != nil {
+		return err
+	}
+
+	_, err = linkTagsTx(tx, jobId, tagIds)
+	return err
+}
+
+// upsertTagsTx returns the IDs of the user's tags with the given names,
+// creating any that do not exist yet.
+func upsertTagsTx(tx *sql.Tx, userId string, names []string) ([]string, error) {
+	tagIds := make([]string, 0, len(names))
+	for _, name := range names {
+		var tagId string
+		err := tx.QueryRow(`
+			INSERT INTO tags(userId, name, createdAt) VALUES($1, $2, NOW())
+			ON CONFLICT (userId, LOWER(name)) DO UPDATE SET name = tags.name
+			RETURNING id
+		`, userId, name).Scan(&tagId)
+		if err != nil {
+			return nil, err
+		}
+		tagIds = append(tagIds, tagId)
+	}
+
+	return tagIds, nil
+}
+
+// linkTagsTx attaches the tags to the job and reports how many it did not
+// already have.
+func linkTagsTx(tx *sql.Tx, jobId string, tagIds []string) (int64, error) {
+	var linked int64
+	for _, tagId := range tagIds {
+		result, err := tx.Exec("INSERT INTO job_tags(jobId, tagId) VALUES($1, $2) ON CONFLICT (jobId, tagId) DO NOTHING", jobId, tagId)
+		if err != nil {
+			return 0, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		linked += rowsAffected
+	}
+
+	return linked, nil
+}
+
+func DetachTag(jobId, tagId string) error {
+	result, err := db.DB.Exec("DELETE FROM job_tags WHERE jobId=$1 AND tagId=$2", jobId, tagId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("tag is not attached to this job")
+	}
+
+	return nil
+}
+
+// LoadJobTags fills in the Tags of each job with a single query.
+func LoadJobTags(jobs []Job) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(jobs))
+	index := make(map[string]int, len(jobs))
+	for i := range jobs {
+		ids[i] = jobs[i].ID
+		index[jobs[i].ID] = i
+		jobs[i].Tags = []string{}
+	}
+
+	query := `
+		SELECT jt.jobId, t.name
+		FROM job_tags jt
+		JOIN tags t ON t.id = jt.tagId
+		WHERE jt.jobId = ANY($1)
+		ORDER BY LOWER(t.name)
+	`
+	rows, err := db.DB.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var jobId, name string
+		if err := rows.Scan(&jobId, &name); err != nil {
+			return err
+		}
+		if i, ok := index[jobId]; ok {
+			jobs[i].Tags = append(jobs[i].Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetTagStats counts the user's jobs per tag and status.
+func GetTagStats(userId string) ([]TagStats, error) {
+	query := `
+		SELECT t.name, j.status, COUNT(*)
+		FROM tags t
+		JOIN job_tags jt ON jt.tagId = t.id
+		JOIN jobs j ON j.id = jt.jobId
+		WHERE t.userId = $1 AND j.deletedAt IS NULL
+		GROUP BY t.name, j.status
+		ORDER BY LOWER(t.name)
+	`
+	rows, err := db.DB.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []TagStats
+	for rows.Next() {
+		var name string
+		var status Status
+		var count int
+		if err := rows.Scan(&name, &status, &count); err != nil {
+			return nil, err
+		}
+
+		if len(stats) == 0 || stats[len(stats)-1].Tag != name {
+			stats = append(stats, TagStats{Tag: name, ByStatus: map[string]int{
+				"accepted":  0,
+				"pending":   0,
+				"interview": 0,
+				"declined":  0,
+			}})
+		}
+		current := &stats[len(stats)-1]
+		current.Total += count
+		current.ByStatus[strings.ToLower(string(status))] += count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,329 @@
+package models
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"jobstar.com/api/db"
+	"jobstar.com/api/utils"
+)
+
+// RecoveryCodeCount is how many recovery codes a user gets at a time.
+const RecoveryCodeCount = 10
+
+// MaxTwoFactorFailures is how many wrong codes in a row lock two-factor
+// verification for TwoFactorLockout.
+const MaxTwoFactorFailures = 5
+
+const TwoFactorLockout = 15 * time.Minute
+
+var (
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication is already on")
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not on")
+	ErrTwoFactorNotSetUp   = errors.New("start two-factor setup first")
+	ErrInvalidTwoFactor    = errors.New("invalid two-factor code")
+	ErrTwoFactorLocked     = errors.New("too many invalid codes, try again later")
+)
+
+type TwoFactorEnableRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// TwoFactorVerifyRequest completes a login. It takes either a code from the
+// authenticator app or one of the recovery codes.
+type TwoFactorVerifyRequest struct {
+	PreAuthToken string `json:"preAuthToken" binding:"required"`
+	Code         string `json:"code" example:"123456"`
+	RecoveryCode string `json:"recoveryCode" example:"k3f9q-x7m2p"`
+}
+
+type TwoFactorPasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// Validate checks exactly one kind of code was given.
+func (r TwoFactorVerifyRequest) Validate() error {
+	if (r.Code == "") == (r.RecoveryCode == "") {
+		return errors.New("please provide either code or recoveryCode")
+	}
+	return nil
+}
+
+// StartTwoFactorSetup creates a new TOTP secret for the user, replacing any
+// from an earlier setup that was not finished. It returns the secret and the
+// user's email for the authenticator app's label.
+func StartTwoFactorSetup(userId string) (string, string, error) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	var email string
+	err = db.DB.QueryRow(`
+		UPDATE users SET totpSecret = $1
+		WHERE id = $2 AND totpEnabled = FALSE AND deletedAt IS NULL
+		RETURNING email
+	`, secret, userId).Scan(&email)
+	if err == sql.ErrNoRows {
+		return "", "", ErrTwoFactorEnabled
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, email, nil
+}
+
+// EnableTwoFactor turns two-factor authentication on once the user proves
+// their app has the secret, and returns their first set of recovery codes.
+func EnableTwoFactor(userId, code string, now time.Time) ([]string, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var secret sql.NullString
+	var enabled bool
+	err = tx.QueryRow("SELECT totpSecret, totpEnabled FROM users WHERE id = $1 FOR UPDATE", userId).Scan(&secret, &enabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if !secret.Valid {
+		return nil, ErrTwoFactorNotSetUp
+	}
+
+	step, ok := utils.ValidateTOTP(secret.String, code, now)
+	if !ok {
+		return nil, ErrInvalidTwoFactor
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET totpEnabled = TRUE, totpLastStep = $1, totpFailedAttempts = 0, totpLockedUntil = NULL
+		WHERE id = $2
+	`, step, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := replaceRecoveryCodes(tx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, tx.Commit()
+}
+
+// VerifyTwoFactor checks the second factor at login, with either a TOTP
+// code or an unused recovery code, and returns the user's email. A TOTP
+// code is only accepted once. Repeated failures lock verification for a
+// while.
+func VerifyTwoFactor(userId, code, recoveryCode string, now time.Time) (string, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var email string
+	var secret sql.NullString
+	var enabled bool
+	var lastStep int64
+	var failures int
+	var lockedUntil sql.NullTime
+	err = tx.QueryRow(`
+		SELECT email, totpSecret, totpEnabled, totpLastStep, totpFailedAttempts, totpLockedUntil
+		FROM users WHERE id = $1 AND deletedAt IS NULL
+		FOR UPDATE
+	`, userId).Scan(&email, &secret, &enabled, &lastStep, &failures, &lockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.New("user not found")
+		}
+		return "", err
+	}
+	if !enabled || !secret.Valid {
+		return "", ErrTwoFactorNotEnabled
+	}
+	if lockedUntil.Valid && now.Before(lockedUntil.Time) {
+		return "", ErrTwoFactorLocked
+	}
+
+	verified := false
+	if code != "" {
+		step, ok := utils.ValidateTOTP(secret.String, code, now)
+		if ok && step > lastStep {
+			verified = true
+			lastStep = step
+		}
+	} else {
+		result, err := tx.Exec(`
+			UPDATE totp_recovery_codes SET usedAt = $1
+			WHERE userId = $2 AND codeHash = $3 AND usedAt IS NULL
+		`, now, userId, hashToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return "", err
+		}
+		used, err := result.RowsAffected()
+		if err != nil {
+			return "", err
+		}
+		verified = used == 1
+	}
+
+	if !verified {
+		failures++
+		var lockUntil interface{}
+		if failures >= MaxTwoFactorFailures {
+			failures, lockUntil = 0, now.Add(TwoFactorLockout)
+		}
+		_, err = tx.Exec("UPDATE users SET totpFailedAttempts = $1, totpLockedUntil = $2 WHERE id = $3", failures, lockUntil, userId)
+		if err != nil {
+			return "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return "", err
+		}
+		if lockUntil != nil {
+			return "", ErrTwoFactorLocked
+		}
+		return "", ErrInvalidTwoFactor
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET totpLastStep = $1, totpFailedAttempts = 0, totpLockedUntil = NULL
+		WHERE id = $2
+	`, lastStep, userId)
+	if err != nil {
+		return "", err
+	}
+
+	return email, tx.Commit()
+}
+
+// DisableTwoFactor turns two-factor authentication off and forgets the
+// secret and recovery codes.
+func DisableTwoFactor(userId string) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users SET totpSecret = NULL, totpEnabled = FALSE, totpLastStep = 0, totpFailedAttempts = 0, totpLockedUntil = NULL
+		WHERE id = $1 AND totpEnabled = TRUE
+	`, userId)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if _, err := tx.Exec("DELETE FROM totp_recovery_codes WHERE userId = $1", userId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, used or not,
+// with a new set.
+func RegenerateRecoveryCodes(userId string) ([]string, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var enabled bool
+	err = tx.QueryRow("SELECT totpEnabled FROM users WHERE id = $1 FOR UPDATE", userId).Scan(&enabled)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	codes, err := replaceRecoveryCodes(tx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, tx.Commit()
+}
+
+// CountRecoveryCodes returns how many of the user's recovery codes are left.
+func CountRecoveryCodes(userId string) (int, error) {
+	var count int
+	err := db.DB.QueryRow("SELECT COUNT(*) FROM totp_recovery_codes WHERE userId = $1 AND usedAt IS NULL", userId).Scan(&count)
+	return count, err
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userId string) ([]string, error) {
+	if _, err := tx.Exec("DELETE FROM totp_recovery_codes WHERE userId = $1", userId); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec("INSERT INTO totp_recovery_codes(userId, codeHash) VALUES($1, $2)", userId, hashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+	}
+
+	return codes, nil
+}
+
+// recoveryCodeAlphabet leaves out characters that are easily confused.
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// generateRecoveryCode returns a code such as "k3f9q-x7m2p".
+func generateRecoveryCode() (string, error) {
+	var b strings.Builder
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+		if err != nil {
+			return "", fmt.Errorf("error generating random number: %w", err)
+		}
+		b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// normalizeRecoveryCode lets codes be typed in any case, with or without
+// the dash.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
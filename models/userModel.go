@@ -1,11 +1,14 @@
 package models
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"jobstar.com/api/db"
+	"jobstar.com/api/geo"
 	"jobstar.com/api/utils"
 )
 
@@ -21,17 +24,61 @@ type User struct {
 	IsVerified        bool   `json:"isVerified"`
 }
 
+// Profile is the part of a user's record that is safe to hand back to them.
+type Profile struct {
+	ID         string `json:"id"`
+	FirstName  string `json:"firstName"`
+	LastName   string `json:"lastName"`
+	Email      string `json:"email"`
+	Location   string `json:"location"`
+	IsVerified bool   `json:"isVerified"`
+
+	TwoFactorEnabled bool `json:"twoFactorEnabled"`
+	Preferences
+
+	// PendingEmail is the address the user is changing to, until they
+	// confirm it
+	PendingEmail   *string       `json:"pendingEmail"`
+	ParsedLocation *geo.Location `json:"parsedLocation"`
+}
+
 type UserLogin struct {
 	ID       string `json:"id"` //id is skipped so that swagger does notpick it
 	Email    string `json:"email"`
 	Password string `json:"password"`
+
+	// TwoFactorEnabled is set by ValidateCredentials
+	TwoFactorEnabled bool `json:"-"`
 }
 
 type UserUpdate struct {
-	ID        string `json:"id"`
-	FirstName string `json:"firstName"`
-	LastName  string `json:"lastName"`
-	Location  string `json:"location"`
+	ID                string `json:"-"`
+	FirstName         string `json:"firstName"`
+	LastName          string `json:"lastName"`
+	Location          string `json:"location"`
+	PreferredCurrency string `json:"preferredCurrency" example:"EUR"`
+}
+
+// Validate checks the details are complete.
+func (u *UserUpdate) Validate() error {
+	if u.FirstName == "" {
+		return errors.New("please provide first name")
+	}
+	if u.LastName == "" {
+		return errors.New("please provide last name")
+	}
+	if u.Location == "" {
+		return errors.New("please provide location")
+	}
+
+	u.PreferredCurrency = strings.ToUpper(strings.TrimSpace(u.PreferredCurrency))
+	if u.PreferredCurrency == "" {
+		u.PreferredCurrency = DefaultCurrency
+	}
+	if !currencyCodePattern.MatchString(u.PreferredCurrency) {
+		return errors.New("preferredCurrency must be a 3-letter ISO 4217 code")
+	}
+	return nil
 }
 
 func (u *User) Save() error {
@@ -40,8 +87,9 @@ func (u *User) Save() error {
 		return fmt.Errorf("database connection is not initialized")
 	}
 
-	query := `INSERT INTO users(firstName, lastName, email, password, location, verificationToken ) 
-	VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+	query := `INSERT INTO users(firstName, lastName, email, password, location, verificationToken,
+		locationCity, locationRegion, locationCountry, locationLat, locationLon)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
 
 	// Prepare and execute the query
 	stmt, err := db.DB.Prepare(query)
@@ -57,7 +105,8 @@ func (u *User) Save() error {
 	}
 
 	// Use QueryRow to execute the query and retrieve the generated ID
-	err = stmt.QueryRow(u.FirstName, u.LastName, u.Email, hashedPassword, u.Location, u.VerificationToken).Scan(&u.ID)
+	args := []interface{}{u.FirstName, u.LastName, u.Email, hashedPassword, u.Location, u.VerificationToken}
+	err = stmt.QueryRow(append(args, locationArgs(geo.Parse(u.Location))...)...).Scan(&u.ID)
 	if err != nil {
 		log.Printf("Error executing query: %v", err)
 		return err
@@ -67,13 +116,13 @@ func (u *User) Save() error {
 }
 
 func (u *UserLogin) ValidateCredentials() error {
-	query := "SELECT id, password, isVerified FROM users WHERE email=$1"
+	query := "SELECT id, password, isVerified, deletedAt IS NOT NULL, totpEnabled FROM users WHERE email=$1"
 
 	var retrievedPassword string
-	var isVerified bool
+	var isVerified, isDeleted bool
 	row := db.DB.QueryRow(query, u.Email)
 	// err := db.DB.QueryRow(query, u.Email).Scan(&u.ID, &retrievedPassword) //binding the password. Weare also binding the UserID so that we can acccess it to generate jwt token during login
-	err := row.Scan(&u.ID, &retrievedPassword, &isVerified) //binding the password. We are also binding the UserID so that we can acccess it to generate jwt token during login
+	err := row.Scan(&u.ID, &retrievedPassword, &isVerified, &isDeleted, &u.TwoFactorEnabled) //binding the password. We are also binding the UserID so that we can acccess it to generate jwt token during login
 
 	if err != nil {
 		fmt.Println(err)
@@ -90,6 +139,10 @@ func (u *UserLogin) ValidateCredentials() error {
 		return errors.New("credentials Invalid")
 	}
 
+	if isDeleted {
+		return ErrAccountPendingDeletion
+	}
+
 	return nil
 }
 
@@ -99,7 +152,9 @@ func (u *UserUpdate) Update() error {
 		return fmt.Errorf("database connection is not initialized")
 	}
 
-	query := `UPDATE users SET firstName=$1, lastName=$2, location=$3 WHERE id=$4`
+	query := `UPDATE users SET firstName=$1, lastName=$2, location=$3, preferredCurrency=$5,
+		locationCity=$6, locationRegion=$7, locationCountry=$8, locationLat=$9, locationLon=$10
+	WHERE id=$4`
 
 	// Prepare the query
 	stmt, err := db.DB.Prepare(query)
@@ -110,7 +165,8 @@ func (u *UserUpdate) Update() error {
 	defer stmt.Close()
 
 	// Execute the query
-	_, err = stmt.Exec(u.FirstName, u.LastName, u.Location, u.ID)
+	args := []interface{}{u.FirstName, u.LastName, u.Location, u.ID, u.PreferredCurrency}
+	_, err = stmt.Exec(append(args, locationArgs(geo.Parse(u.Location))...)...)
 	if err != nil {
 		log.Printf("Error executing query: %v", err)
 		return err
@@ -160,3 +216,46 @@ func Verify(email, token string) error {
 
 	return nil
 }
+
+func GetProfile(userId string) (*Profile, error) {
+	query := "SELECT id, firstName, lastName, email, location, isVerified, totpEnabled, " + preferenceFields + ", " +
+		strings.Join(locationFields, ", ") + ", " +
+		"(SELECT newEmail FROM email_changes WHERE userId = users.id AND expiresAt > NOW())" +
+		" FROM users WHERE id=$1 AND deletedAt IS NULL"
+
+	var profile Profile
+	var preferences preferenceColumns
+	var location locationColumns
+	dest := []interface{}{&profile.ID, &profile.FirstName, &profile.LastName, &profile.Email,
+		&profile.Location, &profile.IsVerified, &profile.TwoFactorEnabled}
+	dest = append(dest, preferences.dest()...)
+	dest = append(dest, location.dest()...)
+	err := db.DB.QueryRow(query, userId).Scan(append(dest, &profile.PendingEmail)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	profile.Preferences = preferences.result()
+	profile.ParsedLocation = location.location()
+
+	return &profile, nil
+}
+
+// GetPreferredCurrency returns the currency the user wants salaries shown in.
+func GetPreferredCurrency(userId string) (string, error) {
+	var currency string
+	err := db.DB.QueryRow("SELECT preferredCurrency FROM users WHERE id=$1", userId).Scan(&currency)
+	return currency, err
+}
+
+// IsAdmin checks whether the user may manage app-wide settings.
+func IsAdmin(userId string) (bool, error) {
+	var isAdmin bool
+	err := db.DB.QueryRow("SELECT isAdmin FROM users WHERE id=$1 AND deletedAt IS NULL", userId).Scan(&isAdmin)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return isAdmin, err
+}
@@ -17,13 +17,100 @@ func RegisterAuthRoutes(router *gin.RouterGroup) {
 	router.POST("/login", controllers.LoginController)
 	router.GET("/verifyAccount", controllers.VerifyAccountController)
 	router.PATCH("/updateUser", middlewares.Authenticate, controllers.UpdateUser)
+	router.PUT("/updateUser", middlewares.Authenticate, controllers.ReplaceUser)
+	router.GET("/me", middlewares.Authenticate, controllers.GetMe)
+	router.GET("/preferences", middlewares.Authenticate, controllers.GetPreferences)
+	router.PATCH("/preferences", middlewares.Authenticate, controllers.UpdatePreferences)
+	router.POST("/email", middlewares.Authenticate, controllers.RequestEmailChange)
+	router.DELETE("/email", middlewares.Authenticate, controllers.CancelEmailChange)
+	router.GET("/email/confirm", controllers.ConfirmEmailChange)
+	router.POST("/2fa/setup", middlewares.Authenticate, controllers.SetupTwoFactor)
+	router.POST("/2fa/enable", middlewares.Authenticate, controllers.EnableTwoFactor)
+	router.POST("/2fa/verify", controllers.VerifyTwoFactor)
+	router.POST("/2fa/recovery-codes", middlewares.Authenticate, controllers.RegenerateRecoveryCodes)
+	router.DELETE("/2fa", middlewares.Authenticate, controllers.DisableTwoFactor)
+	router.GET("/digest", middlewares.Authenticate, controllers.GetDigestSettings)
+	router.PATCH("/digest", middlewares.Authenticate, controllers.UpdateDigestSettings)
+	router.GET("/digest/unsubscribe", controllers.ConfirmUnsubscribeDigest)
+	router.POST("/digest/unsubscribe", controllers.UnsubscribeDigest)
+	router.GET("/calendar", middlewares.Authenticate, controllers.GetCalendarFeed)
+	router.POST("/calendar/reset", middlewares.Authenticate, middlewares.Idempotent, controllers.ResetCalendarFeed)
+	router.GET("/export", middlewares.Authenticate, controllers.ExportAccount)
+	router.DELETE("/account", middlewares.Authenticate, controllers.DeleteAccount)
+	router.POST("/account/restore", controllers.RestoreAccount)
 }
 
 func RegisterJobRoutes(router *gin.RouterGroup) {
-	router.POST("/", middlewares.Authenticate, controllers.CreateJob)
+	router.POST("/", middlewares.Authenticate, middlewares.Idempotent, controllers.CreateJob)
 	router.GET("/", middlewares.Authenticate, controllers.GetJobsByUser)
 	router.GET("/stats", middlewares.Authenticate, controllers.ShowStats)
+	router.POST("/import", middlewares.Authenticate, middlewares.Idempotent, controllers.ImportJobs)
+	router.GET("/export", middlewares.Authenticate, controllers.ExportJobs)
+	router.GET("/trash", middlewares.Authenticate, controllers.GetTrashedJobs)
+	router.GET("/compare", middlewares.Authenticate, controllers.CompareJobs)
+	router.POST("/bulk", middlewares.Authenticate, middlewares.Idempotent, controllers.BulkUpdateJobs)
 	router.GET("/:id", middlewares.Authenticate, controllers.GetSingleJob)
 	router.DELETE("/:id", middlewares.Authenticate, controllers.DeleteJob)
 	router.PATCH("/:id", middlewares.Authenticate, controllers.UpdateJob)
+	router.PUT("/:id", middlewares.Authenticate, controllers.ReplaceJob)
+	router.POST("/:id/restore", middlewares.Authenticate, middlewares.Idempotent, controllers.RestoreJob)
+	router.POST("/:id/merge", middlewares.Authenticate, middlewares.Idempotent, controllers.MergeJobs)
+
+	router.POST("/:id/interviews", middlewares.Authenticate, middlewares.Idempotent, controllers.CreateInterview)
+	router.GET("/:id/interviews", middlewares.Authenticate, controllers.GetJobInterviews)
+	router.GET("/:id/interviews/:interviewId", middlewares.Authenticate, controllers.GetSingleInterview)
+	router.PATCH("/:id/interviews/:interviewId", middlewares.Authenticate, controllers.UpdateInterview)
+	router.DELETE("/:id/interviews/:interviewId", middlewares.Authenticate, controllers.DeleteInterview)
+	router.GET("/:id/interviews/:interviewId/ics", middlewares.Authenticate, controllers.DownloadInterviewICS)
+
+	router.POST("/:id/notes", middlewares.Authenticate, middlewares.Idempotent, controllers.CreateNote)
+	router.GET("/:id/notes", middlewares.Authenticate, controllers.GetJobNotes)
+	router.PATCH("/:id/notes/:noteId", middlewares.Authenticate, controllers.UpdateNote)
+	router.DELETE("/:id/notes/:noteId", middlewares.Authenticate, controllers.DeleteNote)
+	router.GET("/:id/activity", middlewares.Authenticate, controllers.GetJobActivity)
+
+	router.POST("/:id/contacts", middlewares.Authenticate, middlewares.Idempotent, controllers.LinkJobContact)
+	router.GET("/:id/contacts", middlewares.Authenticate, controllers.GetJobContacts)
+	router.DELETE("/:id/contacts/:contactId", middlewares.Authenticate, controllers.UnlinkJobContact)
+
+	router.POST("/:id/documents", middlewares.Authenticate, middlewares.Idempotent, controllers.UploadDocument)
+	router.GET("/:id/documents", middlewares.Authenticate, controllers.GetJobDocuments)
+	router.GET("/:id/documents/:documentId", middlewares.Authenticate, controllers.DownloadDocument)
+	router.DELETE("/:id/documents/:documentId", middlewares.Authenticate, controllers.DeleteDocument)
+
+	router.POST("/:id/tags", middlewares.Authenticate, middlewares.Idempotent, controllers.AddJobTags)
+	router.DELETE("/:id/tags/:tagId", middlewares.Authenticate, controllers.RemoveJobTag)
+}
+
+func RegisterContactRoutes(router *gin.RouterGroup) {
+	router.POST("/", middlewares.Authenticate, middlewares.Idempotent, controllers.CreateContact)
+	router.GET("/", middlewares.Authenticate, controllers.GetContacts)
+	router.GET("/:id", middlewares.Authenticate, controllers.GetSingleContact)
+	router.PATCH("/:id", middlewares.Authenticate, controllers.UpdateContact)
+	router.DELETE("/:id", middlewares.Authenticate, controllers.DeleteContact)
+	router.GET("/:id/jobs", middlewares.Authenticate, controllers.GetContactJobs)
+}
+
+func RegisterTagRoutes(router *gin.RouterGroup) {
+	router.GET("/", middlewares.Authenticate, controllers.GetTags)
+	router.POST("/", middlewares.Authenticate, middlewares.Idempotent, controllers.CreateTag)
+	router.PATCH("/:id", middlewares.Authenticate, controllers.RenameTag)
+	router.DELETE("/:id", middlewares.Authenticate, controllers.DeleteTag)
+	router.POST("/:id/merge", middlewares.Authenticate, middlewares.Idempotent, controllers.MergeTag)
+}
+
+func RegisterCompanyRoutes(router *gin.RouterGroup) {
+	router.GET("/", middlewares.Authenticate, controllers.GetCompanies)
+	router.GET("/:id", middlewares.Authenticate, controllers.GetSingleCompany)
+	router.PATCH("/:id", middlewares.Authenticate, controllers.RenameCompany)
+	router.POST("/:id/aliases", middlewares.Authenticate, middlewares.Idempotent, controllers.AddCompanyAlias)
+}
+
+func RegisterExchangeRateRoutes(router *gin.RouterGroup) {
+	router.GET("/", middlewares.Authenticate, controllers.GetExchangeRates)
+	router.PATCH("/", middlewares.Authenticate, middlewares.RequireAdmin, controllers.UpdateExchangeRates)
+}
+
+func RegisterCalendarRoutes(router *gin.RouterGroup) {
+	router.GET("/:token/interviews.ics", controllers.CalendarFeed)
 }
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLocalStore(t *testing.T) *LocalStore {
+	t.Helper()
+	store, err := NewLocalStore(filepath.Join(t.TempDir(), "uploads"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestLocalStorePath(t *testing.T) {
+	store := newTestLocalStore(t)
+
+	valid := []string{"documents/u1/j1/abc", "a", "/etc/passwd", "a/./b", "a/../b"}
+	for _, key := range valid {
+		path, err := store.path(key)
+		if err != nil {
+			t.Errorf("path(%q): unexpected error %v", key, err)
+			continue
+		}
+		if !strings.HasPrefix(path, store.root+string(filepath.Separator)) {
+			t.Errorf("path(%q) = %q, outside %q", key, path, store.root)
+		}
+	}
+
+	invalid := []string{"", ".", "..", "../outside", "a/../../outside", "documents/../../outside"}
+	for _, key := range invalid {
+		if path, err := store.path(key); err == nil {
+			t.Errorf("path(%q) = %q, want an error", key, path)
+		}
+	}
+}
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+	key := "documents/u1/j1/resume"
+	content := "%PDF-1.7 resume"
+
+	if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	blob, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("Get returned %q, want %q", data, content)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing blob: %v", err)
+	}
+}
+
+func TestLocalStorePutRejectsEscapingKey(t *testing.T) {
+	store := newTestLocalStore(t)
+
+	err := store.Put(context.Background(), "../outside", strings.NewReader("x"), 1, "text/plain")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(store.root), "outside")); !os.IsNotExist(err) {
+		t.Error("file was written outside the root")
+	}
+}
+
+func TestLocalStorePutSizeMismatch(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+	key := "documents/u1/j1/short"
+
+	// The upload ends early: fewer bytes arrive than were declared
+	err := store.Put(ctx, key, strings.NewReader("12345"), 10, "text/plain")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("a truncated blob was left under the key: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(store.root, "documents", "u1", "j1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "eu-west-2"
+	testBucket    = "jobstar-test"
+)
+
+// s3StandIn is a minimal S3-compatible server for tests. It serves
+// path-style object PUT, GET and DELETE for one bucket and checks each
+// request's Signature Version 4, computed independently of S3Store.
+type s3StandIn struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string]s3Object
+}
+
+type s3Object struct {
+	data        []byte
+	contentType string
+}
+
+func newS3StandIn(t *testing.T) (*s3StandIn, *httptest.Server) {
+	standIn := &s3StandIn{t: t, objects: map[string]s3Object{}}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	return standIn, server
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.signatureValid(r) {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+testBucket+"/")
+	if !ok || key == "" {
+		http.Error(w, "<Error><Code>NoSuchBucket</Code></Error>", http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if int64(len(data)) != r.ContentLength {
+			http.Error(w, "<Error><Code>IncompleteBody</Code></Error>", http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = s3Object{data: data, contentType: r.Header.Get("Content-Type")}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		object, ok := s.objects[key]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Write(object.data)
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *s3StandIn) signatureValid(r *http.Request) bool {
+	amzDate := r.Header.Get("X-Amz-Date")
+	payload := r.Header.Get("X-Amz-Content-Sha256")
+	if len(amzDate) != len("20060102T150405Z") || payload == "" {
+		return false
+	}
+	scope := amzDate[:8] + "/" + testRegion + "/s3/aws4_request"
+
+	canonicalRequest := r.Method + "\n" +
+		r.URL.EscapedPath() + "\n" +
+		r.URL.RawQuery + "\n" +
+		"host:" + r.Host + "\n" +
+		"x-amz-content-sha256:" + payload + "\n" +
+		"x-amz-date:" + amzDate + "\n" +
+		"\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" +
+		payload
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + testSecretKey)
+	for _, part := range []string{amzDate[:8], testRegion, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+
+	want := "AWS4-HMAC-SHA256 Credential=" + testAccessKey + "/" + scope +
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" + hex.EncodeToString(key)
+	return r.Header.Get("Authorization") == want
+}
+
+func newTestS3Store(t *testing.T, endpoint, secretKey string) *S3Store {
+	t.Helper()
+	store, err := NewS3Store(S3Config{
+		Endpoint:  endpoint,
+		Region:    testRegion,
+		Bucket:    testBucket,
+		AccessKey: testAccessKey,
+		SecretKey: secretKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	standIn, server := newS3StandIn(t)
+	store := newTestS3Store(t, server.URL, testSecretKey)
+	ctx := context.Background()
+
+	// Spaces and non-ASCII characters must be encoded the same way on both ends
+	key := "documents/u1/j1/offer letter ü+1.pdf"
+	content := "%PDF-1.7 offer"
+
+	if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if object := standIn.objects[key]; object.contentType != "application/pdf" {
+		t.Errorf("stored content type %q, want application/pdf", object.contentType)
+	}
+
+	blob, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("Get returned %q, want %q", data, content)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing object: %v", err)
+	}
+}
+
+func TestS3StoreWrongSecret(t *testing.T) {
+	_, server := newS3StandIn(t)
+	store := newTestS3Store(t, server.URL, "not-the-secret")
+
+	err := store.Put(context.Background(), "documents/a", strings.NewReader("x"), 1, "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("got %v, want a 403 error", err)
+	}
+}
+
+func TestNewS3StoreConfig(t *testing.T) {
+	if _, err := NewS3Store(S3Config{Endpoint: "http://localhost:9000", Bucket: "b", AccessKey: "a"}); err == nil {
+		t.Error("expected an error without a secret key")
+	}
+	if _, err := NewS3Store(S3Config{Endpoint: "localhost", Bucket: "b", AccessKey: "a", SecretKey: "s"}); err == nil {
+		t.Error("expected an error for an endpoint without a scheme")
+	}
+
+	store, err := NewS3Store(S3Config{Endpoint: "http://localhost:9000/", Bucket: "b", AccessKey: "a", SecretKey: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.config.Region != "us-east-1" {
+		t.Errorf("default region %q, want us-east-1", store.config.Region)
+	}
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+)
+
+// ErrNotFound is returned by Get when no blob exists under the key.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores uploaded files by key.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var Store BlobStore
+
+// InitStore sets up the blob store selected by STORAGE_DRIVER ("local" or "s3").
+func InitStore() {
+	driver := os.Getenv("STORAGE_DRIVER")
+
+	switch driver {
+	case "", "local":
+		root := os.Getenv("STORAGE_LOCAL_PATH")
+		if root == "" {
+			root = "./uploads" // Default value if not set
+		}
+
+		store, err := NewLocalStore(root)
+		if err != nil {
+			log.Fatal("Could not initialise local storage:", err)
+		}
+		Store = store
+	case "s3":
+		store, err := NewS3Store(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+		if err != nil {
+			log.Fatal("Could not initialise S3 storage:", err)
+		}
+		Store = store
+	default:
+		log.Fatalf("Unknown STORAGE_DRIVER %q", driver)
+	}
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+)
+
+// EnvInt reads a positive integer from the environment, returning fallback
+// when the variable is unset or invalid.
+func EnvInt(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ICSEvent is a single VEVENT in an iCalendar file.
+type ICSEvent struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Updated     time.Time // when the event last changed, written as LAST-MODIFIED
+	Sequence    int       // revision number; clients only replace an event when it goes up
+}
+
+const icsTimeFormat = "20060102T150405Z"
+
+// BuildICS renders the events as an RFC 5545 calendar generated at now.
+// Times are written in UTC so calendar clients convert them to the viewer's
+// own timezone.
+func BuildICS(calendarName string, events []ICSEvent, now time.Time) string {
+	var b strings.Builder
+	stamp := now.UTC().Format(icsTimeFormat)
+
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//JobStar//Interviews//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME:"+escapeICSText(calendarName))
+
+	for _, event := range events {
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+event.UID)
+		writeICSLine(&b, "DTSTAMP:"+stamp)
+		writeICSLine(&b, "LAST-MODIFIED:"+event.Updated.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, fmt.Sprintf("SEQUENCE:%d", event.Sequence))
+		writeICSLine(&b, "DTSTART:"+event.Start.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, "DTEND:"+event.End.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(event.Summary))
+		if event.Description != "" {
+			writeICSLine(&b, "DESCRIPTION:"+escapeICSText(event.Description))
+		}
+		if event.Location != "" {
+			writeICSLine(&b, "LOCATION:"+escapeICSText(event.Location))
+		}
+		if event.URL != "" {
+			writeICSLine(&b, "URL:"+event.URL)
+		}
+		writeICSLine(&b, "END:VEVENT")
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+
+	return b.String()
+}
+
+func escapeICSText(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(s)
+}
+
+// writeICSLine folds lines longer than 75 octets as required by RFC 5545,
+// taking care not to split a multi-byte character.
+func writeICSLine(b *strings.Builder, line string) {
+	limit := 75
+
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isUTF8Start(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isUTF8Start(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeICSText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain", "plain"},
+		{"Acme, Inc.", `Acme\, Inc.`},
+		{"round 1; onsite", `round 1\; onsite`},
+		{`C:\path`, `C:\\path`},
+		{"line one\nline two", `line one\nline two`},
+		{"line one\r\nline two", `line one\nline two`},
+		{`a\,b`, `a\\\,b`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeICSText(tt.text); got != tt.want {
+			t.Errorf("escapeICSText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestWriteICSLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"short", "SUMMARY:Interview", "SUMMARY:Interview\r\n"},
+		{"exactly 75 octets", strings.Repeat("a", 75), strings.Repeat("a", 75) + "\r\n"},
+		{"76 octets", strings.Repeat("a", 76), strings.Repeat("a", 75) + "\r\n a\r\n"},
+		{
+			"continuation lines hold 74 octets after the space",
+			strings.Repeat("a", 75+74+1),
+			strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n",
+		},
+		{
+			// é is two octets starting at octet 75, so it moves to the next line whole
+			"multi-byte character at the fold",
+			strings.Repeat("a", 74) + "é",
+			strings.Repeat("a", 74) + "\r\n é\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeICSLine(&b, tt.line)
+			if got := b.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildICS(t *testing.T) {
+	now := time.Date(2024, 9, 1, 8, 0, 0, 0, time.UTC)
+	start := time.Date(2024, 9, 10, 14, 0, 0, 0, time.FixedZone("BST", 3600))
+	event := ICSEvent{
+		UID:         "abc@jobstar",
+		Start:       start,
+		End:         start.Add(time.Hour),
+		Summary:     "Interview: Backend Engineer at Acme, Inc.",
+		Description: "Interviewer: Jane Doe\nVideo link: https://meet.example.com/" + strings.Repeat("x", 80),
+		Updated:     time.Date(2024, 8, 20, 9, 30, 0, 0, time.UTC),
+		Sequence:    3,
+	}
+
+	calendar := BuildICS("JobStar interviews", []ICSEvent{event}, now)
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:abc@jobstar\r\n",
+		"DTSTAMP:20240901T080000Z\r\n",
+		"LAST-MODIFIED:20240820T093000Z\r\n",
+		"SEQUENCE:3\r\n",
+		"DTSTART:20240910T130000Z\r\n",
+		"DTEND:20240910T140000Z\r\n",
+		`SUMMARY:Interview: Backend Engineer at Acme\, Inc.` + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(calendar, want) {
+			t.Errorf("calendar is missing %q", want)
+		}
+	}
+	if strings.Contains(calendar, "LOCATION:") {
+		t.Error("empty location should be left out")
+	}
+
+	if !strings.HasSuffix(calendar, "\r\n") {
+		t.Error("calendar does not end with CRLF")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(calendar, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line is %d octets long: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a character: %q", line)
+		}
+		if strings.Contains(line, "\n") {
+			t.Errorf("bare line feed in %q", line)
+		}
+	}
+}
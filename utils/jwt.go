@@ -1,56 +1,109 @@
 package utils
 
 import (
+	"errors"
 	"time"
+
 	"github.com/golang-jwt/jwt/v5"
-	"errors"
 )
 
-const secretKey="mySecretKey"
+const secretKey = "mySecretKey"
+
+// preAuthPurpose marks a token that only proves the password was right. It
+// is exchanged for a normal token once the second factor has been checked,
+// and is not accepted anywhere else.
+const preAuthPurpose = "2fa"
+
 func GenerateToken(email string, userId string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"email": email,
-		"userId":userId,
-		"exp": time.Now().Add(time.Hour*2).Unix(), //make token valid for only 2 hours
+		"email":  email,
+		"userId": userId,
+		"exp":    time.Now().Add(time.Hour * 2).Unix(), //make token valid for only 2 hours
 	})
 
 	signedToken, err := token.SignedString([]byte(secretKey))
 	return signedToken, err
 }
 
+// GeneratePreAuthToken issues the short-lived token handed out at login to
+// users with two-factor authentication turned on.
+func GeneratePreAuthToken(userId string, ttl time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId":  userId,
+		"purpose": preAuthPurpose,
+		"exp":     time.Now().Add(ttl).Unix(),
+	})
 
-func VerifyToken(token string) (string, error){
+	return token.SignedString([]byte(secretKey))
+}
 
-parsedToken, err := jwt.Parse(token, func(token *jwt.Token)(interface{}, error){
+func parseClaims(token string) (jwt.MapClaims, error) {
+	parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
 		_, ok := token.Method.(*jwt.SigningMethodHMAC) //...HS256 is a type of HMAC
-		
+
 		//this checks for the signin methods to ensure it's same that was used during login
-		if !ok{
+		if !ok {
 			return nil, errors.New("Unexpected signing method")
 		}
 
 		return []byte(secretKey), nil
 	})
 
-	if err != nil{
-		return "", errors.New("could not parse token: " + err.Error())
+	if err != nil {
+		return nil, errors.New("could not parse token: " + err.Error())
 	}
 
 	tokenIsValid := parsedToken.Valid
 
-	if !tokenIsValid{
-		return "", errors.New ("Invalid token")
+	if !tokenIsValid {
+		return nil, errors.New("Invalid token")
 	}
 
 	claims, ok := parsedToken.Claims.(jwt.MapClaims)
 
-	if !ok{
-		return "", errors.New ("Invalid token claims")
+	if !ok {
+		return nil, errors.New("Invalid token claims")
+	}
+
+	return claims, nil
+}
+
+func VerifyToken(token string) (string, error) {
+	claims, err := parseClaims(token)
+	if err != nil {
+		return "", err
+	}
+
+	// A pre-auth token must not pass for a login
+	if _, ok := claims["purpose"]; ok {
+		return "", errors.New("Invalid token")
 	}
 
 	// email:=claims["email"].(string)
 	// userId:=int64(claims["userId"].(float64))
-	userId:= claims["userId"].(string)
+	userId, ok := claims["userId"].(string)
+	if !ok {
+		return "", errors.New("Invalid token claims")
+	}
+
+	return userId, nil
+}
+
+// VerifyPreAuthToken returns the user a pre-auth token was issued to.
+func VerifyPreAuthToken(token string) (string, error) {
+	claims, err := parseClaims(token)
+	if err != nil {
+		return "", err
+	}
+
+	if purpose, _ := claims["purpose"].(string); purpose != preAuthPurpose {
+		return "", errors.New("Invalid token")
+	}
+
+	userId, ok := claims["userId"].(string)
+	if !ok {
+		return "", errors.New("Invalid token claims")
+	}
 
 	return userId, nil
 }
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+	// maxOffset is far past the end of any listing, so a page that would
+	// skip more rows than this is simply empty
+	maxOffset = math.MaxInt32
+)
+
+// Pagination describes one page of a listing and is returned alongside it.
+type Pagination struct {
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	Total      int `json:"total"`
+	TotalPages int `json:"totalPages"`
+}
+
+// ParsePagination reads the page and limit query parameters, falling back to
+// sensible defaults for missing or out-of-range values.
+func ParsePagination(c *gin.Context) Pagination {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	return Pagination{Page: page, Limit: limit}
+}
+
+// Offset is the number of rows to skip to reach the current page. It is
+// capped at maxOffset, so huge page numbers cannot overflow.
+func (p Pagination) Offset() int {
+	if p.Limit > 0 && p.Page-1 > maxOffset/p.Limit {
+		return maxOffset
+	}
+	return (p.Page - 1) * p.Limit
+}
+
+// SetTotal records the total row count and derives the number of pages.
+func (p *Pagination) SetTotal(total int) {
+	p.Total = total
+	p.TotalPages = (total + p.Limit - 1) / p.Limit
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPaginationOffset(t *testing.T) {
+	tests := []struct {
+		page, limit int
+		want        int
+	}{
+		{1, 20, 0},
+		{3, 20, 40},
+		{maxOffset/100 + 1, 100, maxOffset / 100 * 100},
+		{maxOffset/100 + 2, 100, maxOffset},
+		{math.MaxInt64, 100, maxOffset},
+		{math.MaxInt64, 1, maxOffset},
+	}
+
+	for _, tt := range tests {
+		if got := (Pagination{Page: tt.page, Limit: tt.limit}).Offset(); got != tt.want {
+			t.Errorf("page %d, limit %d: got %d, want %d", tt.page, tt.limit, got, tt.want)
+		}
+	}
+}
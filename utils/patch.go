@@ -0,0 +1,341 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// ErrUnsupportedPatchType is returned for patch bodies in a media type
+// ApplyPatch does not understand.
+var ErrUnsupportedPatchType = errors.New("unsupported patch media type, use application/merge-patch+json or application/json-patch+json")
+
+// ApplyPatch applies patch to the JSON document original and returns the
+// result. contentType picks the format: an RFC 7396 merge patch (also
+// assumed for plain application/json) or an RFC 6902 JSON Patch.
+func ApplyPatch(contentType string, original, patch []byte) ([]byte, error) {
+	switch contentType {
+	case MergePatchType, "application/json", "":
+		return MergePatch(original, patch)
+	case JSONPatchType:
+		return JSONPatch(original, patch)
+	}
+	return nil, ErrUnsupportedPatchType
+}
+
+// MergePatch applies an RFC 7396 merge patch: members of the patch replace
+// those of the document, objects are merged recursively and null removes a
+// member.
+func MergePatch(original, patch []byte) ([]byte, error) {
+	var document, changes interface{}
+	if err := decodeJSON(original, &document); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(patch, &changes); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+
+	return json.Marshal(mergeValue(document, changes))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+
+	for key, value := range changes {
+		if value == nil {
+			delete(object, key)
+			continue
+		}
+		object[key] = mergeValue(object[key], value)
+	}
+
+	return object
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// JSONPatch applies an RFC 6902 JSON Patch. Operations run in order and the
+// whole patch fails if any of them does, including a failed test.
+func JSONPatch(original, patch []byte) ([]byte, error) {
+	var document interface{}
+	if err := decodeJSON(original, &document); err != nil {
+		return nil, err
+	}
+
+	var operations []patchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("invalid JSON patch, expected an array of operations: %w", err)
+	}
+
+	for i, operation := range operations {
+		var err error
+		document, err = applyOperation(document, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, operation.Op, err)
+		}
+	}
+
+	return json.Marshal(document)
+}
+
+func applyOperation(document interface{}, operation patchOperation) (interface{}, error) {
+	if operation.Path == nil {
+		return nil, errors.New("missing path")
+	}
+	path, err := parsePointer(*operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var from []string
+	switch operation.Op {
+	case "move", "copy":
+		if operation.From == nil {
+			return nil, errors.New("missing from")
+		}
+		from, err = parsePointer(*operation.From)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var value interface{}
+	switch operation.Op {
+	case "add", "replace", "test":
+		if operation.Value == nil {
+			return nil, errors.New("missing value")
+		}
+		if err := decodeJSON(operation.Value, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch operation.Op {
+	case "add":
+		return addValue(document, path, value)
+
+	case "remove":
+		return removeValue(document, path)
+
+	case "replace":
+		if _, err := getValue(document, path); err != nil {
+			return nil, err
+		}
+		document, err = removeValue(document, path)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(document, path, value)
+
+	case "move":
+		if *operation.Path != *operation.From && strings.HasPrefix(*operation.Path, *operation.From+"/") {
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+		value, err := getValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+		document, err = removeValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(document, path, value)
+
+	case "copy":
+		value, err := getValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+		value, err = deepCopy(value)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(document, path, value)
+
+	case "test":
+		current, err := getValue(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("test failed at %q", *operation.Path)
+		}
+		return document, nil
+	}
+
+	return nil, fmt.Errorf("unknown op %q", operation.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid path %q, must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func getValue(document interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := document.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q does not exist", token)
+			}
+			document = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			document = container[index]
+		default:
+			return nil, fmt.Errorf("path member %q does not exist", token)
+		}
+	}
+	return document, nil
+}
+
+func addValue(document interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateParent(document, path, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			if token == "-" {
+				return append(container, value), nil
+			}
+			index, err := arrayIndex(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("cannot add %q to a value that is not an object or array", token)
+	})
+}
+
+func removeValue(document interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("cannot remove the whole document")
+	}
+
+	return updateParent(document, path, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case map[string]interface{}:
+			if _, ok := container[token]; !ok {
+				return nil, fmt.Errorf("path member %q does not exist", token)
+			}
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(container[:index], container[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("path member %q does not exist", token)
+	})
+}
+
+// updateParent finds the container holding the last token of path, lets
+// change modify it and stores the result back in its own parent. Arrays may
+// be reallocated by change, so every level has to be written back.
+func updateParent(document interface{}, path []string, change func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return change(document, path[0])
+	}
+
+	child, err := getValue(document, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updateParent(child, path[1:], change)
+	if err != nil {
+		return nil, err
+	}
+
+	switch container := document.(type) {
+	case map[string]interface{}:
+		container[path[0]] = child
+	case []interface{}:
+		index, _ := arrayIndex(path[0], len(container)-1)
+		container[index] = child
+	}
+	return document, nil
+}
+
+// arrayIndex parses an array index token, which must be a plain decimal no
+// greater than max.
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index > max {
+		return 0, fmt.Errorf("array index %q is out of range", token)
+	}
+	return index, nil
+}
+
+func deepCopy(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var copied interface{}
+	return copied, decodeJSON(data, &copied)
+}
+
+// decodeJSON decodes with numbers kept as json.Number, so large integers
+// such as salaries survive the round trip exactly.
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfc6238Key is the SHA-1 seed from RFC 6238 Appendix B.
+const rfc6238Key = "12345678901234567890"
+
+// rfc6238Vectors are the SHA-1 test values from RFC 6238 Appendix B.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "94287082"},
+	{1111111109, "07081804"},
+	{1111111111, "14050471"},
+	{1234567890, "89005924"},
+	{2000000000, "69279037"},
+	{20000000000, "65353130"},
+}
+
+func rfc6238Secret() string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte(rfc6238Key))
+}
+
+func TestHOTPEightDigits(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		step := TOTPStep(time.Unix(v.unix, 0))
+		if got := hotp([]byte(rfc6238Key), step, 8); got != v.code {
+			t.Errorf("T=%d: got %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestTOTPCode(t *testing.T) {
+	secret := rfc6238Secret()
+	for _, v := range rfc6238Vectors {
+		// Six digits are the last six of the RFC's eight
+		want := v.code[2:]
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("T=%d: %v", v.unix, err)
+		}
+		if got != want {
+			t.Errorf("T=%d: got %s, want %s", v.unix, got, want)
+		}
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("expected an error for an invalid secret")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := rfc6238Secret()
+	now := time.Unix(1111111111, 0)
+	step := TOTPStep(now)
+
+	tests := []struct {
+		name     string
+		code     string
+		now      time.Time
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current period", "050471", now, step, true},
+		{"spaces are ignored", " 050 471 ", now, step, true},
+		{"previous period within skew", "081804", now, step - 1, true},
+		{"next period within skew", "050471", now.Add(-TOTPPeriod), step, true},
+		{"outside skew", "050471", now.Add(2 * TOTPPeriod), 0, false},
+		{"wrong code", "123456", now, 0, false},
+		{"eight digits", "14050471", now, 0, false},
+		{"empty", "", now, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(secret, tt.code, tt.now)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("got (%d, %v), want (%d, %v)", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
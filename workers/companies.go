@@ -0,0 +1,22 @@
+package workers
+
+import (
+	"log"
+
+	"jobstar.com/api/models"
+)
+
+// StartCompanyBackfill links jobs saved before companies existed to their
+// company in the background. New and updated jobs are linked as they are
+// saved, so this only has work to do once after upgrading.
+func StartCompanyBackfill() {
+	go func() {
+		linked, err := models.LinkUnlinkedJobs()
+		if err != nil {
+			log.Printf("Error linking jobs to companies: %v", err)
+		}
+		if linked > 0 {
+			log.Printf("Linked %d jobs to companies", linked)
+		}
+	}()
+}
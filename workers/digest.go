@@ -0,0 +1,158 @@
+package workers
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"jobstar.com/api/email"
+	"jobstar.com/api/models"
+	"jobstar.com/api/utils"
+)
+
+const digestCheckInterval = 10 * time.Minute
+
+// StartDigestWorker periodically sends the weekly digest to every subscriber
+// whose chosen day and hour has come round in their own timezone.
+func StartDigestWorker() {
+	go func() {
+		ticker := time.NewTicker(digestCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			sendDueDigests(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func sendDueDigests(now time.Time) {
+	subscribers, err := models.GetDigestSubscribers()
+	if err != nil {
+		log.Printf("Error fetching digest subscribers: %v", err)
+		return
+	}
+
+	for _, subscriber := range subscribers {
+		if !digestIsDue(subscriber, now) {
+			continue
+		}
+
+		err := sendDigest(subscriber, now)
+		if err != nil {
+			log.Printf("Error sending digest to user %s: %v", subscriber.UserID, err)
+			continue
+		}
+
+		if err := models.MarkDigestSent(subscriber.UserID, now); err != nil {
+			log.Printf("Error marking digest sent for user %s: %v", subscriber.UserID, err)
+		}
+	}
+}
+
+func digestIsDue(subscriber models.DigestSubscriber, now time.Time) bool {
+	location, err := time.LoadLocation(subscriber.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	local := now.In(location)
+	if int(local.Weekday()) != subscriber.Day || local.Hour() != subscriber.Hour {
+		return false
+	}
+
+	// The worker wakes up several times within the hour; only send once a week
+	return subscriber.LastSentAt == nil || now.Sub(*subscriber.LastSentAt) > 24*time.Hour
+}
+
+func sendDigest(subscriber models.DigestSubscriber, now time.Time) error {
+	staleDays := int(utils.EnvInt("DIGEST_STALE_DAYS", 21))
+
+	since := now.AddDate(0, 0, -7)
+	digest, err := models.GetWeeklyDigest(subscriber.UserID, since, now.AddDate(0, 0, -staleDays))
+	if err != nil {
+		return err
+	}
+
+	unsubscribeLink := fmt.Sprintf("%s/api/v1/auth/digest/unsubscribe?t=%s", os.Getenv("APIHostURL"), subscriber.Token)
+
+	subject := "Your weekly JobStar digest"
+	location, err := time.LoadLocation(subscriber.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	body := renderDigest(digest, staleDays, location, subscriber.Locale, unsubscribeLink)
+
+	// One-click unsubscribe, per RFC 8058: mail clients POST to the link
+	headers := map[string]string{
+		"List-Unsubscribe":      "<" + unsubscribeLink + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+
+	return email.SendEmailWithHeaders(subscriber.Email, subject, subscriber.FirstName, body, headers)
+}
+
+func renderDigest(digest *models.WeeklyDigest, staleDays int, location *time.Location, locale, unsubscribeLink string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "<p>Here is what happened in your job search this week.</p>")
+	fmt.Fprintf(&b, "<p><strong>Pipeline:</strong> %d pending, %d interviewing, %d accepted, %d declined.</p>",
+		digest.Totals["pending"], digest.Totals["interview"], digest.Totals["accepted"], digest.Totals["declined"])
+
+	fmt.Fprintf(&b, "<h3>Applications this week (%d)</h3>", len(digest.NewApplications))
+	writeJobList(&b, digest.NewApplications, location, locale)
+
+	fmt.Fprintf(&b, "<h3>Status changes (%d)</h3>", len(digest.StatusChanges))
+	if len(digest.StatusChanges) == 0 {
+		b.WriteString("<p>None.</p>")
+	} else {
+		b.WriteString("<ul>")
+		for _, change := range digest.StatusChanges {
+			fmt.Fprintf(&b, "<li>%s at %s: %s &rarr; %s</li>",
+				html.EscapeString(change.Position), html.EscapeString(change.Company),
+				html.EscapeString(string(change.FromStatus)), html.EscapeString(string(change.ToStatus)))
+		}
+		b.WriteString("</ul>")
+	}
+
+	fmt.Fprintf(&b, "<h3>Upcoming interviews (%d)</h3>", len(digest.UpcomingInterviews))
+	if len(digest.UpcomingInterviews) == 0 {
+		b.WriteString("<p>None.</p>")
+	} else {
+		b.WriteString("<ul>")
+		for _, interview := range digest.UpcomingInterviews {
+			fmt.Fprintf(&b, "<li>%s: %s at %s", email.FormatDateTime(interview.ScheduledAt.In(location), locale),
+				html.EscapeString(interview.Position), html.EscapeString(interview.Company))
+			if interview.RoundName != "" {
+				fmt.Fprintf(&b, " (%s)", html.EscapeString(interview.RoundName))
+			}
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ul>")
+	}
+
+	fmt.Fprintf(&b, "<h3>No response for %d+ days (%d)</h3>", staleDays, len(digest.StaleApplications))
+	writeJobList(&b, digest.StaleApplications, location, locale)
+
+	fmt.Fprintf(&b, `<p style="font-size:12px;color:#777777">Don't want these emails? <a href="%s">Unsubscribe</a>.</p>`, unsubscribeLink)
+
+	return b.String()
+}
+
+func writeJobList(b *strings.Builder, jobs []models.Job, location *time.Location, locale string) {
+	if len(jobs) == 0 {
+		b.WriteString("<p>None.</p>")
+		return
+	}
+
+	b.WriteString("<ul>")
+	for _, job := range jobs {
+		fmt.Fprintf(b, "<li>%s at %s (%s)</li>",
+			html.EscapeString(job.Position), html.EscapeString(job.Company), email.FormatDay(job.CreatedAt.In(location), locale))
+	}
+	b.WriteString("</ul>")
+}
@@ -0,0 +1,29 @@
+package workers
+
+import (
+	"log"
+	"time"
+
+	"jobstar.com/api/models"
+)
+
+const idempotencyCleanupInterval = time.Hour
+
+// StartIdempotencyCleanupWorker periodically deletes idempotency keys whose
+// stored responses have expired.
+func StartIdempotencyCleanupWorker() {
+	go func() {
+		ticker := time.NewTicker(idempotencyCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			deleted, err := models.DeleteExpiredIdempotencyKeys()
+			if err != nil {
+				log.Printf("Error deleting expired idempotency keys: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Deleted %d expired idempotency keys", deleted)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,22 @@
+package workers
+
+import (
+	"log"
+
+	"jobstar.com/api/models"
+)
+
+// StartLocationBackfill parses, in the background, the locations of jobs and
+// users saved before locations were parsed. Text the gazetteer still does not
+// recognise is looked at again on the next start, in case it has grown.
+func StartLocationBackfill() {
+	go func() {
+		parsed, err := models.ParseUnparsedLocations()
+		if err != nil {
+			log.Printf("Error parsing locations: %v", err)
+		}
+		if parsed > 0 {
+			log.Printf("Parsed %d locations", parsed)
+		}
+	}()
+}
@@ -0,0 +1,61 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"jobstar.com/api/models"
+	"jobstar.com/api/storage"
+)
+
+const purgeCheckInterval = time.Hour
+
+// StartAccountPurgeWorker periodically hard-deletes accounts whose deletion
+// grace period has run out, along with their uploaded files.
+func StartAccountPurgeWorker() {
+	go func() {
+		ticker := time.NewTicker(purgeCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			purgeDueAccounts(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func purgeDueAccounts(now time.Time) {
+	userIds, err := models.GetAccountsDueForPurge(now)
+	if err != nil {
+		log.Printf("Error fetching accounts due for purging: %v", err)
+		return
+	}
+
+	for _, userId := range userIds {
+		if err := purgeAccount(userId); err != nil {
+			log.Printf("Error purging account %s: %v", userId, err)
+		}
+	}
+}
+
+// purgeAccount deletes the user's files before their records, so a failure
+// part way leaves the records in place for the next run to try again. The
+// account cannot be restored by then, so nothing is lost by going first.
+func purgeAccount(userId string) error {
+	documents, err := models.GetUserDocuments(userId)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, document := range documents {
+		err := storage.Store.Delete(ctx, document.StorageKey)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+
+	return models.PurgeAccount(userId)
+}
@@ -0,0 +1,91 @@
+package workers
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"jobstar.com/api/email"
+	"jobstar.com/api/models"
+)
+
+const reminderCheckInterval = 5 * time.Minute
+
+// StartInterviewReminderWorker periodically emails users about interviews
+// coming up within the lead time they chose, once per interview.
+func StartInterviewReminderWorker() {
+	go func() {
+		ticker := time.NewTicker(reminderCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			sendDueReminders(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func sendDueReminders(now time.Time) {
+	reminders, err := models.GetDueInterviewReminders(now)
+	if err != nil {
+		log.Printf("Error fetching due interview reminders: %v", err)
+		return
+	}
+
+	for _, reminder := range reminders {
+		err := sendReminder(reminder)
+		if err != nil {
+			log.Printf("Error sending reminder for interview %s: %v", reminder.Interview.ID, err)
+			continue
+		}
+
+		if err := models.MarkInterviewReminderSent(reminder.Interview.ID, now); err != nil {
+			log.Printf("Error marking reminder sent for interview %s: %v", reminder.Interview.ID, err)
+		}
+	}
+}
+
+func sendReminder(reminder models.InterviewReminder) error {
+	location, err := time.LoadLocation(reminder.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	interview := reminder.Interview
+	subject := fmt.Sprintf("Reminder: interview with %s", interview.Company)
+	body := renderReminder(interview, location, reminder.Locale)
+
+	return email.SendEmail(reminder.Email, subject, reminder.FirstName, body)
+}
+
+func renderReminder(interview models.JobInterview, location *time.Location, locale string) string {
+	var b strings.Builder
+
+	round := "interview"
+	if interview.RoundName != "" {
+		round = html.EscapeString(interview.RoundName)
+	}
+	fmt.Fprintf(&b, "<p>Your %s for %s at %s is coming up.</p>", round,
+		html.EscapeString(interview.Position), html.EscapeString(interview.Company))
+
+	b.WriteString("<ul>")
+	fmt.Fprintf(&b, "<li><strong>When:</strong> %s (%s), %d minutes</li>",
+		email.FormatDateTime(interview.ScheduledAt.In(location), locale), location, interview.DurationMinutes)
+	if interview.Interviewer != "" {
+		fmt.Fprintf(&b, "<li><strong>With:</strong> %s</li>", html.EscapeString(interview.Interviewer))
+	}
+	if interview.Location != "" {
+		fmt.Fprintf(&b, "<li><strong>Where:</strong> %s</li>", html.EscapeString(interview.Location))
+	}
+	if interview.VideoLink != "" {
+		link := html.EscapeString(interview.VideoLink)
+		fmt.Fprintf(&b, `<li><strong>Link:</strong> <a href="%s">%s</a></li>`, link, link)
+	}
+	b.WriteString("</ul>")
+
+	b.WriteString(`<p style="font-size:12px;color:#777777">You can change when reminders are sent, or turn them off, in your preferences.</p>`)
+
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"jobstar.com/api/models"
+	"jobstar.com/api/storage"
+	"jobstar.com/api/utils"
+)
+
+// StartJobTrashPurgeWorker periodically hard-deletes jobs that have been in
+// the trash for longer than the retention period, along with their
+// uploaded documents.
+func StartJobTrashPurgeWorker() {
+	go func() {
+		ticker := time.NewTicker(purgeCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			purgeTrashedJobs(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func purgeTrashedJobs(now time.Time) {
+	retentionDays := int(utils.EnvInt("JOB_TRASH_RETENTION_DAYS", 30))
+
+	cutoff := now.AddDate(0, 0, -retentionDays)
+	jobIds, err := models.GetJobsDueForPurge(cutoff)
+	if err != nil {
+		log.Printf("Error fetching jobs due for purging: %v", err)
+		return
+	}
+
+	for _, jobId := range jobIds {
+		if err := purgeJob(jobId, cutoff); err != nil {
+			log.Printf("Error purging job %s: %v", jobId, err)
+		}
+	}
+}
+
+// purgeJob removes the job's files before the job itself, for the same
+// reason as purgeAccount. Restoring is refused from cutoff on, so the job
+// cannot come back without its files.
+func purgeJob(jobId string, cutoff time.Time) error {
+	documents, err := models.GetJobDocuments(jobId)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, document := range documents {
+		err := storage.Store.Delete(ctx, document.StorageKey)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+
+	return models.PurgeJob(jobId, cutoff)
+}